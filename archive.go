@@ -0,0 +1,103 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Prepares the archived version of a submitted report under a new report ID, it's only saved once the report is posted.
+// If the storage fails we still want the report to be posted, in that case the returned ID is 0.
+func archiveSubmittedReport(report *reportData, userID string) *archivedReport {
+	reportID, idErr := reportStorage.nextReportID()
+	if idErr != nil {
+		logStorageError("generate a new report ID", idErr)
+		reportID = 0
+	}
+
+	archived := &archivedReport{
+		ID:          reportID,
+		ReporterID:  userID,
+		ReportType:  report.reportType.ID,
+		SubmittedAt: time.Now(),
+		Answers:     generateArchivedAnswers(report),
+		Attachments: getAttachmentLinks(report),
+		LogSummary:  generateLogSummary(report),
+		ExtraFields: report.hookFields,
+		Status:      reportStatusOpen,
+	}
+
+	return archived
+}
+
+// Only the questions that were actually asked end up in the archive, together with the attachments linked to them
+func generateArchivedAnswers(report *reportData) []archivedAnswer {
+	answers := make([]archivedAnswer, 0, len(report.data))
+	for index, value := range report.data {
+		if !isQuestionActive(report, index) {
+			continue
+		}
+
+		answers = append(answers, archivedAnswer{
+			QuestionID:   value.question.ID,
+			Question:     value.question.Question,
+			PrettyFormat: value.question.PrettyFormat,
+			Answer:       value.answer,
+			Skipped:      value.skipped,
+			Attachments:  getQuestionAttachmentLinks(report, index),
+		})
+	}
+
+	return answers
+}
+
+func saveArchivedReportToStorage(report *archivedReport) {
+	if report.ID == 0 {
+		return
+	}
+
+	if saveErr := reportStorage.saveArchivedReport(report); saveErr != nil {
+		logStorageError("save archived report #"+strconv.FormatUint(report.ID, 10), saveErr)
+	}
+}
+
+func replaceReportIDPlaceholder(content string, reportID uint64) string {
+	return strings.ReplaceAll(content, "{{REPORT_ID}}", strconv.FormatUint(reportID, 10))
+}
+
+type archivedReport struct {
+	ID          uint64                  `json:"id"`
+	ReporterID  string                  `json:"reporter_id"`
+	ReportType  string                  `json:"report_type"`
+	SubmittedAt time.Time               `json:"submitted_at"`
+	Answers     []archivedAnswer        `json:"answers"`
+	Attachments []string                `json:"attachments"`
+	ChannelID   string                  `json:"channel_id"`
+	MessageID   string                  `json:"message_id"`
+	ThreadID    string                  `json:"thread_id,omitempty"`
+	LogSummary  string                  `json:"log_summary,omitempty"`
+	ExtraFields []reportField           `json:"extra_fields,omitempty"`
+	IssueID     string                  `json:"issue_id,omitempty"`
+	IssueURL    string                  `json:"issue_url,omitempty"`
+	Content     string                  `json:"content"`
+	Embed       *discordgo.MessageEmbed `json:"embed,omitempty"`
+
+	Status          reportStatus `json:"status"`
+	StatusUpdatedBy string       `json:"status_updated_by,omitempty"`
+	StatusUpdatedAt time.Time    `json:"status_updated_at,omitempty"`
+	StaffNote       string       `json:"staff_note,omitempty"`
+
+	// The threads of the copies in the other report channels
+	CopiedThreadIDs []string `json:"copied_thread_ids,omitempty"`
+}
+
+type archivedAnswer struct {
+	QuestionID   string   `json:"question_id,omitempty"`
+	Question     string   `json:"question"`
+	PrettyFormat string   `json:"pretty_format"`
+	Answer       string   `json:"answer"`
+	Skipped      bool     `json:"skipped,omitempty"`
+	Attachments  []string `json:"attachments,omitempty"`
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const bytesPerMegabyte = 1024 * 1024
+
+// Checks an attachment against the attachment policy, returns the message for the user when it isn't allowed
+// Works out which attachments of a message will be added to the report, without adding them yet.
+// Attachments that are accepted count towards the limits of the ones after them, just like when they're added.
+func findAcceptedAttachments(report *reportData, attachments []*discordgo.MessageAttachment) []bool {
+	accepted := make([]bool, len(attachments))
+	acceptedCount := len(report.attachments)
+	acceptedSize := 0
+	for index, attachment := range attachments {
+		if uint(acceptedCount) >= config.ReportMaxAttachments {
+			break
+		}
+
+		if checkAttachmentPolicy(report, attachment, acceptedSize) != "" {
+			continue
+		}
+
+		accepted[index] = true
+		acceptedCount++
+		acceptedSize += attachment.Size
+	}
+
+	return accepted
+}
+
+// The pending size is the size of the attachments that will be added to the report before this one
+func checkAttachmentPolicy(report *reportData, attachment *discordgo.MessageAttachment, pendingSize int) (rejection string) {
+	policy := config.AttachmentPolicy
+
+	if !isAllowedAttachmentType(attachment) {
+		return formatAttachmentRejection(config.Messages.AttachmentTypeNotAllowed, attachment, 0)
+	}
+
+	if policy.MaxFileSizeMB > 0 && float64(attachment.Size) > policy.MaxFileSizeMB*bytesPerMegabyte {
+		return formatAttachmentRejection(config.Messages.AttachmentTooLarge, attachment, policy.MaxFileSizeMB)
+	}
+
+	if policy.MaxTotalSizeMB > 0 && float64(getAttachmentsSize(report)+pendingSize+attachment.Size) > policy.MaxTotalSizeMB*bytesPerMegabyte {
+		return formatAttachmentRejection(config.Messages.AttachmentsTotalTooLarge, attachment, policy.MaxTotalSizeMB)
+	}
+
+	return ""
+}
+
+// Without any allowed types or extensions configured everything is allowed, otherwise one of them has to match.
+// Content types can end with a wildcard, for example "image/*".
+func isAllowedAttachmentType(attachment *discordgo.MessageAttachment) bool {
+	policy := config.AttachmentPolicy
+	if len(policy.AllowedContentTypes) == 0 && len(policy.AllowedExtensions) == 0 {
+		return true
+	}
+
+	extension := strings.ToLower(path.Ext(attachment.Filename))
+	for _, allowedExtension := range policy.AllowedExtensions {
+		if extension != "" && strings.ToLower("."+strings.TrimPrefix(allowedExtension, ".")) == extension {
+			return true
+		}
+	}
+
+	// The content type can come with parameters, for example "text/plain; charset=utf-8"
+	contentType := strings.ToLower(strings.TrimSpace(strings.SplitN(attachment.ContentType, ";", 2)[0]))
+	for _, allowedContentType := range policy.AllowedContentTypes {
+		allowedContentType = strings.ToLower(allowedContentType)
+		if contentType == "" {
+			break
+		}
+
+		if strings.HasSuffix(allowedContentType, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(allowedContentType, "*")) {
+			return true
+		}
+		if contentType == allowedContentType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func formatAttachmentRejection(baseString string, attachment *discordgo.MessageAttachment, maxSizeMB float64) string {
+	baseString = strings.ReplaceAll(baseString, "{{FILE_NAME}}", attachment.Filename)
+	return strings.ReplaceAll(baseString, "{{MAX_SIZE}}", strconv.FormatFloat(maxSizeMB, 'f', -1, 64)+" MB")
+}
+
+// Some report types are useless without a screenshot, those can't be submitted until there is at least one image
+func isScreenshotMissing(report *reportData) bool {
+	if report.reportType == nil || !report.reportType.RequireScreenshot {
+		return false
+	}
+
+	for _, attachment := range report.attachments {
+		if isImageLink(attachment.link) {
+			return false
+		}
+	}
+
+	return true
+}
+
+type attachmentPolicyConfig struct {
+	AllowedContentTypes []string `json:"allowed_content_types,omitempty"`
+	AllowedExtensions   []string `json:"allowed_extensions,omitempty"`
+	MaxFileSizeMB       float64  `json:"max_file_size_mb,omitempty"`
+	MaxTotalSizeMB      float64  `json:"max_total_size_mb,omitempty"`
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	attachmentStorageNone    = "none"
+	attachmentStorageDiscord = "discord"
+	attachmentStorageLocal   = "local"
+	attachmentStorageS3      = "s3"
+
+	// Links to files that are uploaded together with the report, Discord fills these in once the message is posted
+	discordAttachmentPrefix = "attachment://"
+
+	maxAttachmentDownloadSize = 25 * 1024 * 1024
+
+	// The upload limit of servers without boosts, larger uploads would make posting the whole report fail
+	maxDiscordUploadSize      = 10 * 1024 * 1024
+	attachmentDownloadTimeout = 30 * time.Second
+)
+
+var attachmentHTTPClient = &http.Client{Timeout: attachmentDownloadTimeout}
+
+type rehostedAttachment struct {
+	name        string
+	contentType string
+	data        []byte
+}
+
+// Catches storage settings that would only go wrong once the first report is submitted
+func prepareAttachmentStorage() error {
+	switch config.AttachmentStorage.Type {
+	case "", attachmentStorageNone, attachmentStorageDiscord, attachmentStorageS3:
+		return nil
+	case attachmentStorageLocal:
+		// A path on the machine of the bot means nothing to the staff, so the files have to be served somewhere
+		if config.AttachmentStorage.PublicURL == "" {
+			return errors.New("local attachment storage needs a public URL")
+		}
+		return nil
+	default:
+		return errors.New("unknown attachment storage type \"" + config.AttachmentStorage.Type + "\"")
+	}
+}
+
+// The attachment links of Discord expire after a while, so when submitting a report the attachments are downloaded
+// and stored somewhere that lasts. When an attachment can't be rehosted the original link is kept.
+// The returned attachments still have to be uploaded together with the report, this is only the case for Discord.
+func rehostAttachments(report *reportData, archived *archivedReport) []rehostedAttachment {
+	if config.AttachmentStorage.Type == "" || config.AttachmentStorage.Type == attachmentStorageNone || len(report.attachments) == 0 {
+		return nil
+	}
+
+	folder := strconv.FormatUint(archived.ID, 10)
+	if archived.ID == 0 {
+		folder = "unknown-" + strconv.FormatInt(archived.SubmittedAt.Unix(), 10)
+	}
+
+	uploads := make([]rehostedAttachment, 0)
+	uploadSize := 0
+	for index, reportAttachment := range report.attachments {
+		attachment, downloadErr := downloadAttachment(reportAttachment.link, index)
+		if downloadErr != nil {
+			log.Println("Unable to download an attachment of a report: " + downloadErr.Error())
+			continue
+		}
+
+		var durableLink string
+		var storeErr error
+		switch config.AttachmentStorage.Type {
+		case attachmentStorageDiscord:
+			if uploadSize+len(attachment.data) > maxDiscordUploadSize {
+				continue
+			}
+
+			uploadSize += len(attachment.data)
+			uploads = append(uploads, attachment)
+			durableLink = discordAttachmentPrefix + attachment.name
+		case attachmentStorageLocal:
+			durableLink, storeErr = storeAttachmentLocally(folder, attachment)
+		case attachmentStorageS3:
+			durableLink, storeErr = uploadAttachmentToS3(config.AttachmentStorage.S3, folder+"/"+attachment.name, attachment)
+		default:
+			storeErr = errors.New("unknown attachment storage type \"" + config.AttachmentStorage.Type + "\"")
+		}
+
+		if storeErr != nil {
+			log.Println("Unable to rehost an attachment of a report: " + storeErr.Error())
+			continue
+		}
+
+		report.attachments[index].link = durableLink
+	}
+
+	archived.Answers = generateArchivedAnswers(report)
+	archived.Attachments = getAttachmentLinks(report)
+	return uploads
+}
+
+func downloadAttachment(attachmentLink string, index int) (rehostedAttachment, error) {
+	response, getErr := attachmentHTTPClient.Get(attachmentLink)
+	if getErr != nil {
+		return rehostedAttachment{}, getErr
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return rehostedAttachment{}, errors.New("unexpected status " + response.Status + " for " + attachmentLink)
+	}
+
+	data, readErr := ioutil.ReadAll(io.LimitReader(response.Body, maxAttachmentDownloadSize+1))
+	if readErr != nil {
+		return rehostedAttachment{}, readErr
+	}
+	if len(data) > maxAttachmentDownloadSize {
+		return rehostedAttachment{}, errors.New("the attachment " + attachmentLink + " is too large to rehost")
+	}
+
+	// The index keeps two attachments with the same name apart
+	name := strconv.Itoa(index+1) + "-" + sanitizeAttachmentName(path.Base(strings.SplitN(attachmentLink, "?", 2)[0]))
+	return rehostedAttachment{
+		name:        name,
+		contentType: response.Header.Get("Content-Type"),
+		data:        data,
+	}, nil
+}
+
+func storeAttachmentLocally(folder string, attachment rehostedAttachment) (string, error) {
+	directory := filepath.Join(filepath.FromSlash(config.AttachmentStorage.Directory), folder)
+	if mkdirErr := os.MkdirAll(directory, 0755); mkdirErr != nil {
+		return "", mkdirErr
+	}
+
+	filePath := filepath.Join(directory, attachment.name)
+	if writeErr := writeFileAtomically(filePath, attachment.data); writeErr != nil {
+		return "", writeErr
+	}
+
+	return strings.TrimSuffix(config.AttachmentStorage.PublicURL, "/") + "/" + folder + "/" + attachment.name, nil
+}
+
+// Readers can only be read once, so every message that uploads the attachments needs its own files
+func generateAttachmentFiles(attachments []rehostedAttachment) []*discordgo.File {
+	files := make([]*discordgo.File, len(attachments))
+	for index, attachment := range attachments {
+		files[index] = &discordgo.File{
+			Name:        attachment.name,
+			ContentType: attachment.contentType,
+			Reader:      bytes.NewReader(attachment.data),
+		}
+	}
+
+	return files
+}
+
+// Once the report is posted Discord knows the real links of the uploaded attachments, those end up in the archive.
+// The attachments of the questions point to the same files, so they're updated as well.
+func updatePostedAttachmentLinks(archived *archivedReport) {
+	message, messageErr := botSession.ChannelMessage(archived.ChannelID, archived.MessageID)
+	if messageErr != nil {
+		return
+	}
+
+	postedLinks := make(map[string]string, len(message.Attachments))
+	for _, attachment := range message.Attachments {
+		postedLinks[discordAttachmentPrefix+attachment.Filename] = attachment.URL
+	}
+
+	replacePostedAttachmentLinks(archived.Attachments, postedLinks)
+	for _, answer := range archived.Answers {
+		replacePostedAttachmentLinks(answer.Attachments, postedLinks)
+	}
+}
+
+func replacePostedAttachmentLinks(links []string, postedLinks map[string]string) {
+	for index, attachmentLink := range links {
+		if postedLink, ok := postedLinks[attachmentLink]; ok {
+			links[index] = postedLink
+		}
+	}
+}
+
+// Files that are uploaded with the report are shown by their name, the link itself wouldn't work outside of an embed
+func formatAttachmentLink(attachmentLink string) string {
+	return strings.TrimPrefix(attachmentLink, discordAttachmentPrefix)
+}
+
+func sanitizeAttachmentName(name string) string {
+	sanitized := strings.Map(func(character rune) rune {
+		if (character >= 'a' && character <= 'z') || (character >= 'A' && character <= 'Z') || (character >= '0' && character <= '9') || character == '.' || character == '-' || character == '_' {
+			return character
+		}
+		return '_'
+	}, name)
+
+	if sanitized == "" || sanitized == "." || sanitized == ".." {
+		return "attachment"
+	}
+
+	return sanitized
+}
+
+type attachmentStorageConfig struct {
+	Type      string   `json:"type"`
+	Directory string   `json:"directory,omitempty"`
+	PublicURL string   `json:"public_url,omitempty"`
+	S3        s3Config `json:"s3"`
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"log"
 	"os"
@@ -38,6 +39,35 @@ func init() {
 		log.Println("Unable to unmarshal file \"config.json\", are you sure the format is correct?")
 		panic(jsonErr)
 	}
+
+	if config.ReportSafeMessageLength <= 0 || config.ReportSafeMessageLength > maxMessageLength {
+		log.Println("The \"message_safe_length\" in \"config.json\" has to be between 1 and " + strconv.Itoa(maxMessageLength) + "!")
+		panic(errors.New("invalid message safe length"))
+	}
+
+	oversizedErr := prepareOversizedReports()
+	if oversizedErr != nil {
+		log.Println("The messages in \"config.json\" that are added to a report are too long, there's no room left for the report!")
+		panic(oversizedErr)
+	}
+
+	reportTypesErr := prepareReportTypes()
+	if reportTypesErr != nil {
+		log.Println("Unable to use the report types and questions in \"config.json\", are you sure they are correct?")
+		panic(reportTypesErr)
+	}
+
+	attachmentStorageErr := prepareAttachmentStorage()
+	if attachmentStorageErr != nil {
+		log.Println("Unable to use the attachment storage in \"config.json\", are you sure it is correct?")
+		panic(attachmentStorageErr)
+	}
+
+	logExtractorsErr := prepareLogExtractors()
+	if logExtractorsErr != nil {
+		log.Println("Unable to use the log extractors in \"config.json\", are you sure the patterns are correct?")
+		panic(logExtractorsErr)
+	}
 }
 
 func main() {
@@ -47,10 +77,44 @@ func main() {
 		panic(connectErr)
 	}
 
+	var storageErr error
+	reportStorage, storageErr = openReportStore(config.Storage)
+	if storageErr != nil {
+		log.Println("Unable to open the storage for ongoing reports!")
+		panic(storageErr)
+	}
+	defer reportStorage.close()
+
+	var queueErr error
+	outboundWebhookQueue, queueErr = openWebhookQueue(config.OutboundWebhooks)
+	if queueErr != nil {
+		log.Println("Unable to open the queue for the outbound webhooks!")
+		panic(queueErr)
+	}
+
+	var trackerErr error
+	reportIssueTracker, trackerErr = openIssueTracker(config.IssueTracker)
+	if trackerErr != nil {
+		log.Println("Unable to use the issue tracker in \"config.json\"!")
+		panic(trackerErr)
+	}
+
+	if config.TrackerWebhooks.ListenAddress != "" {
+		go startTrackerWebhookServer()
+	}
+
+	restoredUserIDs, droppedUserIDs := restoreOngoingReports()
+	restoreRelayConversations()
+
 	botSession.AddHandler(handleIncomingMessage)
 	botSession.AddHandler(handleInteractions)
 
+	// Direct Messages always come with their content, messages in the guild only do with the privileged intent.
+	// It has to be turned on in the developer portal first, otherwise Discord refuses the connection.
 	botSession.Identify.Intents = discordgo.IntentsDirectMessages | discordgo.IntentsGuildMessages
+	if config.UseMessageContentIntent {
+		botSession.Identify.Intents |= discordgo.IntentsMessageContent
+	}
 
 	connectErr = botSession.Open()
 	if connectErr != nil {
@@ -58,7 +122,13 @@ func main() {
 	}
 	defer botSession.Close()
 
+	registerSlashCommands()
+
 	go startCleanupTimer()
+	if outboundWebhookQueue != nil {
+		go startWebhookDelivery()
+	}
+	go notifyRestoredReports(restoredUserIDs, droppedUserIDs)
 
 	log.Println("Bot is online!")
 
@@ -73,9 +143,10 @@ func markReportAsActive(report *reportData) {
 	report.lastInteraction = time.Now()
 }
 
-func continueOngoingReport(report *reportData, content, userID string, message *discordgo.MessageCreate) {
+// The log findings belong to the attachments of the message, in the same order
+func continueOngoingReport(report *reportData, content, userID string, message *discordgo.MessageCreate, logFindings [][]logFinding) {
 	// Handle attachements, if this returns true there was at least 1 attachment found
-	if handleAttachments(report, userID, message) {
+	if handleAttachments(report, userID, message, logFindings) {
 		if report.isInSubmitMenu {
 			handleSubmittingProcess(report, userID)
 		}
@@ -84,6 +155,11 @@ func continueOngoingReport(report *reportData, content, userID string, message *
 
 	lowerCaseContent := strings.ToLower(content)
 	if report.canSubmit && lowerCaseContent == config.BotDMCommandPrefix+config.BotDMCommandSubmit {
+		if isScreenshotMissing(report) {
+			sendMessageToDM(config.Messages.ScreenshotRequired, userID)
+			return
+		}
+
 		// Someone wants to submit their report, lets do it!
 		handleFinalSubmission(report, userID)
 		return
@@ -91,7 +167,26 @@ func continueOngoingReport(report *reportData, content, userID string, message *
 
 	if lowerCaseContent == config.BotDMCommandPrefix+config.BotDMCommandCancel {
 		// Someone wants to cancel their report
-		deleteOngoingReport(userID)
+		fireOngoingReportEvent(reportEventCancelled, userID, report)
+		deleteOngoingReport(report, userID)
+		return
+	}
+
+	if lowerCaseContent == config.BotDMCommandPrefix+config.BotDMCommandAttachments {
+		// Someone wants to see what they've attached so far
+		sendMessageToDM(generateAttachmentList(report), userID)
+		return
+	}
+
+	if strings.Split(lowerCaseContent, " ")[0] == config.BotDMCommandPrefix+config.BotDMCommandRemoveAttachment {
+		// Someone wants to get rid of one of their attachments
+		handleRemoveAttachment(report, userID, content)
+		return
+	}
+
+	if report.isChoosingType {
+		// The user still has to tell us what kind of report this is
+		handleReportTypeChoice(report, userID, content)
 		return
 	}
 
@@ -101,7 +196,15 @@ func continueOngoingReport(report *reportData, content, userID string, message *
 		return
 	}
 
-	if !isValidFixedQuestionAnswer(report, content) {
+	if report.shouldReadAnswer && lowerCaseContent == config.BotDMCommandPrefix+config.BotDMCommandSkip {
+		// Someone wants to skip the current question
+		handleSkipQuestion(report, userID)
+		return
+	}
+
+	// The answer is validated and stored without the whitespace around it
+	answer := strings.TrimSpace(content)
+	if !isValidFixedQuestionAnswer(report, answer) {
 		baseFormat := config.Messages.InvalidFixedQuestionAnswer
 		for _, value := range report.data[report.currentQuestionIndex].question.FixedAnswers {
 			baseFormat += "\n- " + value
@@ -111,44 +214,93 @@ func continueOngoingReport(report *reportData, content, userID string, message *
 		return
 	}
 
+	if report.shouldReadAnswer {
+		if errorMessage, valid := validateAnswer(report, answer); !valid {
+			sendMessageToDM(errorMessage, userID)
+			return
+		}
+	}
+
 	// From here on it's always a valid response, spam bots will get stuck on fixed questions and will eventually timeout
 	// while regular users will most likely never be that long stuck on one single question
 	markReportAsActive(report)
 
 	if report.shouldReadAnswer {
-		report.data[report.currentQuestionIndex].answer = strings.ReplaceAll(content, "@", "at")
+		report.data[report.currentQuestionIndex].answer = strings.ReplaceAll(answer, "@", "at")
+		report.data[report.currentQuestionIndex].skipped = false
 	}
 
-	// If this validates true that means we are at the end of the report!
-	if report.currentQuestionIndex+1 == uint(len(report.data)) || report.hasReachedEnd {
-		if report.hasReachedEnd {
-			report.currentQuestionIndex = uint(len(report.data)) - 1
-		}
+	moveToNextQuestion(report, userID)
+}
+
+func moveToNextQuestion(report *reportData, userID string) {
+	// After an edit only the questions that became active because of the new answer still have to be asked
+	nextIndex, found := findNextQuestionIndex(report, int(report.currentQuestionIndex), report.hasReachedEnd)
+
+	// If there is no next question that means we are at the end of the report!
+	if !found {
+		report.currentQuestionIndex = uint(len(report.data)) - 1
 		handleSubmittingProcess(report, userID)
 		return
 	}
 
-	report.currentQuestionIndex += 1
+	report.currentQuestionIndex = uint(nextIndex)
 	sendReportQuestion(report, userID, false)
 }
 
-func handleAttachments(report *reportData, userID string, message *discordgo.MessageCreate) (attachedAttachements bool) {
-	if len(message.Attachments) == 0 {
+func handleSkipQuestion(report *reportData, userID string) {
+	if !report.data[report.currentQuestionIndex].question.Optional {
+		sendMessageToDM(config.Messages.CantSkipQuestion, userID)
+		return
+	}
+
+	markReportAsActive(report)
+
+	report.data[report.currentQuestionIndex].answer = ""
+	report.data[report.currentQuestionIndex].skipped = true
+
+	moveToNextQuestion(report, userID)
+}
+
+func handleAttachments(report *reportData, userID string, message *discordgo.MessageCreate, logFindings [][]logFinding) (attachedAttachements bool) {
+	// Buttons and select menus don't come with a message
+	if message == nil || len(message.Attachments) == 0 {
 		return false
 	}
 
 	affectedItems := 0
-	for _, attachment := range message.Attachments {
+	rejectedItems := 0
+	for index, attachment := range message.Attachments {
 		if uint(len(report.attachments)) >= config.ReportMaxAttachments {
 			break
 		}
 
-		report.attachments = append(report.attachments, attachment.ProxyURL)
+		if rejection := checkAttachmentPolicy(report, attachment, 0); rejection != "" {
+			sendMessageToDM(rejection, userID)
+			rejectedItems += 1
+			continue
+		}
+
+		var findings []logFinding
+		if index < len(logFindings) {
+			findings = logFindings[index]
+		}
+
+		report.attachments = append(report.attachments, reportAttachment{
+			link:          attachment.ProxyURL,
+			fileName:      attachment.Filename,
+			size:          attachment.Size,
+			logFindings:   findings,
+			questionIndex: getAttachmentQuestionIndex(report),
+		})
 		affectedItems += 1
 	}
 
 	if affectedItems == 0 {
-		sendMessageToDM(config.Messages.ReachedMaxAttachments, userID)
+		// The user already knows why the attachments were rejected
+		if rejectedItems == 0 {
+			sendMessageToDM(config.Messages.ReachedMaxAttachments, userID)
+		}
 		return true
 	}
 
@@ -161,6 +313,7 @@ func handleAttachments(report *reportData, userID string, message *discordgo.Mes
 	}
 
 	baseString = strings.ReplaceAll(baseString, "{{ATTACHMENTS_LEFT}}", strconv.Itoa(int(config.ReportMaxAttachments)-len(report.attachments)))
+	baseString = strings.ReplaceAll(baseString, "{{ATTACHMENTS_COMMAND}}", config.BotDMCommandPrefix+config.BotDMCommandAttachments)
 	sendMessageToDM(baseString, userID)
 
 	return true
@@ -179,7 +332,7 @@ func handleEditReport(report *reportData, userID, content string) {
 		return
 	}
 
-	if value <= 0 || value > len(report.data) {
+	if value <= 0 || value > len(report.data) || !isQuestionActive(report, value-1) {
 		sendMessageToDM(config.Messages.ValidReportNumber, userID)
 		return
 	}
@@ -192,8 +345,57 @@ func handleEditReport(report *reportData, userID, content string) {
 }
 
 func handleFinalSubmission(report *reportData, userID string) {
-	finalReport, _ := generateFinalBugReport(report, false, false, userID)
-	botSession.ChannelMessageSend(config.ReportChannelID, finalReport)
+	// When the submission hook vetoes the report or posting it fails it stays in the submit menu, so the user can try again
+	submittedMessage, _ := submitReport(report, userID)
+	sendMessageToDM(submittedMessage, userID)
+}
+
+// Posts and archives the report, the returned message is meant for the reporter
+func submitReport(report *reportData, userID string) (submittedMessage string, submitted bool) {
+	if vetoReason, vetoed := runSubmissionHook(report, userID); vetoed {
+		return strings.ReplaceAll(config.Messages.ReportVetoed, "{{VETO_REASON}}", vetoReason), false
+	}
+
+	// Rehosting changes the links of the attachments, those are needed again when the report has to be submitted again
+	originalAttachments := append([]reportAttachment{}, report.attachments...)
+
+	archived := archiveSubmittedReport(report, userID)
+	uploads := rehostAttachments(report, archived)
+
+	messages := generateReportMessages(report, archived, userID)
+	messages[0].Files = append(messages[0].Files, generateAttachmentFiles(uploads)...)
+	if archived.ID != 0 {
+		messages[0].Components = generateTriageComponents(archived.ID)
+	}
+
+	// Without the report in its channel nobody would ever see it, the user keeps the report so they can try again
+	channelIDs := findReportChannels(report)
+	if postErr := postReport(report, archived, channelIDs[0], messages); postErr != nil {
+		log.Println("Unable to post the report of user " + userID + ": " + postErr.Error())
+		report.attachments = originalAttachments
+		return config.Messages.ReportPostFailed, false
+	}
+
+	if len(uploads) > 0 {
+		updatePostedAttachmentLinks(archived)
+	}
+
+	// The other channels get a copy of the report, the status is only kept up to date in the first channel.
+	// The threads of the copies are remembered so the staff can talk to the reporter from there as well.
+	for _, channelID := range channelIDs[1:] {
+		copiedReport := *archived
+		copiedMessages := generateReportMessages(report, &copiedReport, userID)
+		copiedMessages[0].Files = append(copiedMessages[0].Files, generateAttachmentFiles(uploads)...)
+		if copyErr := postReport(report, &copiedReport, channelID, copiedMessages); copyErr == nil && copiedReport.ThreadID != "" {
+			archived.CopiedThreadIDs = append(archived.CopiedThreadIDs, copiedReport.ThreadID)
+		}
+	}
+
+	saveArchivedReportToStorage(archived)
+
+	handleIssueTrackerSubmission(archived)
+
+	fireArchivedReportEvent(reportEventSubmitted, archived)
 
 	// Invalidate the report
 	report.canEdit = false
@@ -202,14 +404,88 @@ func handleFinalSubmission(report *reportData, userID string) {
 	report.isInSubmitMenu = false
 
 	// Set report cooldown
-	setReportCooldownForUser(userID)
+	setReportCooldownForUser(userID, report.reportType)
 
 	// Remove from cache
-	removeReportAndUserFromCache(userID)
+	removeReportAndUserFromCache(userID, report)
 
+	// Without an ID the report couldn't be archived, there's no number the reporter could refer to
 	baseString := config.Messages.SuccessfullySubmittedReport
-	baseString = strings.ReplaceAll(baseString, "{{REPORT_COOLDOWN}}", strconv.Itoa(int(config.ReportCooldownMinutes)))
-	sendMessageToDM(baseString, userID)
+	if archived.ID == 0 {
+		baseString = config.Messages.SubmittedReportWithoutID
+	}
+	baseString = strings.ReplaceAll(baseString, "{{REPORT_COOLDOWN}}", strconv.Itoa(int(*report.reportType.CooldownMinutes)))
+	return replaceReportIDPlaceholder(baseString, archived.ID), true
+}
+
+// Generates the messages a report is posted with, this is a single message unless the report is too large
+// and the config allows oversized reports to be split or attached as a file.
+func generateReportMessages(report *reportData, archived *archivedReport, userID string) []*discordgo.MessageSend {
+	header := ""
+	if archived.ID != 0 {
+		header = strings.ReplaceAll(config.Messages.ReportHeader, "{{REPORT_TYPE}}", report.reportType.Name)
+		header = replaceReportIDPlaceholder(header, archived.ID)
+	}
+
+	parts := generateFinalBugReportParts(report, false, false, userID)
+	fullReport := header + strings.Join(parts, "")
+
+	if isEmbedOutput() {
+		// Without an ID there's nothing to put in the title except the report type
+		title := report.reportType.Name
+		if archived.ID != 0 {
+			title = strings.ReplaceAll(config.Messages.ReportEmbedTitle, "{{REPORT_TYPE}}", report.reportType.Name)
+			title = replaceReportIDPlaceholder(title, archived.ID)
+		}
+
+		embed, tooLarge := generateReportEmbed(report, title, userID)
+
+		if !tooLarge || !canPostOversizedReports() {
+			archived.Embed = embed
+			return []*discordgo.MessageSend{{Embeds: []*discordgo.MessageEmbed{embed}}}
+		}
+
+		embeds := splitReportEmbed(embed)
+		archived.Embed = embeds[0]
+		if config.OversizedReports == oversizedReportsFile {
+			archived.Content = config.Messages.ReportAttachedAsFile
+			return []*discordgo.MessageSend{{
+				Content: archived.Content,
+				Embeds:  embeds[:1],
+				Files:   []*discordgo.File{generateReportFile(archived.ID, fullReport)},
+			}}
+		}
+
+		messages := make([]*discordgo.MessageSend, len(embeds))
+		for index, value := range embeds {
+			messages[index] = &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{value}}
+		}
+		return messages
+	}
+
+	contentLimit := getReportContentLimit()
+	if len(fullReport) <= contentLimit || !canPostOversizedReports() {
+		archived.Content = fullReport
+		return []*discordgo.MessageSend{{Content: fullReport}}
+	}
+
+	if config.OversizedReports == oversizedReportsFile {
+		summaryLength := contentLimit - len(config.Messages.ReportAttachedAsFile)
+		archived.Content = splitReportContent(header, parts, summaryLength)[0] + config.Messages.ReportAttachedAsFile
+		return []*discordgo.MessageSend{{
+			Content: archived.Content,
+			Files:   []*discordgo.File{generateReportFile(archived.ID, fullReport)},
+		}}
+	}
+
+	chunks := splitReportContent(header, parts, contentLimit)
+	archived.Content = chunks[0]
+
+	messages := make([]*discordgo.MessageSend, len(chunks))
+	for index, value := range chunks {
+		messages[index] = &discordgo.MessageSend{Content: value}
+	}
+	return messages
 }
 
 func handleSubmittingProcess(report *reportData, userID string) {
@@ -221,14 +497,18 @@ func handleSubmittingProcess(report *reportData, userID string) {
 	report.shouldReadAnswer = false
 	report.isInSubmitMenu = true
 
-	finalReport, tooLarge := generateFinalBugReport(report, true, false, userID)
+	finalReport, previewTooLarge := generateFinalBugReport(report, true, false, userID)
 
+	// The preview is always text, but an embed can hold a lot more than a single message
 	var baseString string
-	if tooLarge {
+	if isReportTooLarge(report, userID) {
 		report.canSubmit = false
 		finalReport, _ = generateFinalBugReport(report, true, true, userID)
 		baseString = config.Messages.ReportTooLargeWarning
 	} else {
+		if previewTooLarge {
+			finalReport, _ = generateFinalBugReport(report, true, true, userID)
+		}
 		baseString = config.Messages.FinalReportSubmitAlmostReady
 	}
 
@@ -236,25 +516,57 @@ func handleSubmittingProcess(report *reportData, userID string) {
 	baseString = strings.ReplaceAll(baseString, "{{SUBMIT_COMMAND}}", config.BotDMCommandPrefix+config.BotDMCommandSubmit)
 	baseString = strings.ReplaceAll(baseString, "{{EDIT_COMMAND}}", config.BotDMCommandPrefix+config.BotDMCommandEdit)
 
+	// Better to tell the user now than when they try to submit
+	if isScreenshotMissing(report) {
+		baseString += "\n\n" + config.Messages.ScreenshotRequired
+	}
+
 	sendMessageToDM(baseString, userID)
-	sendMessageToDM(finalReport, userID)
+	sendComplexMessageToDM(&discordgo.MessageSend{
+		Content:    finalReport,
+		Components: generateSubmitMenuComponents(report),
+	}, userID)
 }
 
-func deleteOngoingReport(userID string) {
+func deleteOngoingReport(report *reportData, userID string) {
 	sendMessageToDM(config.Messages.CancellingReport, userID)
-	removeReportAndUserFromCache(userID)
+	removeReportAndUserFromCache(userID, report)
 }
 
-func removeReportAndUserFromCache(userID string) {
+// Only removes the report when it's still the current one of the user, a newer report is left alone
+func removeReportAndUserFromCache(userID string, report *reportData) (removed bool) {
 	currentReportsMutex.Lock()
-	defer currentReportsMutex.Unlock()
+	if current, ok := currentOngoingReports[userID]; ok && current == report {
+		delete(currentOngoingReports, userID)
+		removed = true
+	}
+	currentReportsMutex.Unlock()
 
-	delete(currentOngoingReports, userID)
+	if removed {
+		removeOngoingReportFromStorage(userID)
+	}
+	return removed
 }
 
 func generateFinalBugReport(report *reportData, highlightQuestionNumber, safeMode bool, userID string) (finalReport string, tooLarge bool) {
-	var builder strings.Builder
+	result := strings.Join(generateFinalBugReportParts(report, highlightQuestionNumber, safeMode, userID), "")
+	return result, len(result) > config.ReportSafeMessageLength
+}
+
+// Every part starts with its own separator, so joining them gives the complete report.
+// Splitting a report is done on these parts, that way a question and its answer stay together.
+func generateFinalBugReportParts(report *reportData, highlightQuestionNumber, safeMode bool, userID string) []string {
+	parts := make([]string, 0, len(report.data)+2)
 	for index, value := range report.data {
+		if !isQuestionShown(report, index, highlightQuestionNumber) {
+			continue
+		}
+
+		var builder strings.Builder
+		if len(parts) > 0 {
+			builder.WriteString("\n\n")
+		}
+
 		if highlightQuestionNumber {
 			builder.WriteString("**#")
 			builder.WriteString(strconv.Itoa(index + 1))
@@ -262,7 +574,9 @@ func generateFinalBugReport(report *reportData, highlightQuestionNumber, safeMod
 		}
 		builder.WriteString(value.question.PrettyFormat)
 		builder.WriteString("\n")
-		if safeMode {
+		if value.skipped {
+			builder.WriteString(config.Messages.SkippedAnswer)
+		} else if safeMode {
 			if len(value.answer) >= 100 {
 				builder.WriteString(value.answer[:97])
 				builder.WriteString("...")
@@ -272,57 +586,149 @@ func generateFinalBugReport(report *reportData, highlightQuestionNumber, safeMod
 		} else {
 			builder.WriteString(value.answer)
 		}
-		if index != len(report.data)-1 {
-			builder.WriteString("\n\n")
+
+		for _, attachmentLink := range getQuestionAttachmentLinks(report, index) {
+			builder.WriteString("\n")
+			builder.WriteString(formatAttachmentLink(attachmentLink))
 		}
+
+		parts = append(parts, builder.String())
 	}
 
-	if len(report.attachments) > 0 {
+	if generalAttachments := getGeneralAttachmentLinks(report, highlightQuestionNumber); len(generalAttachments) > 0 {
+		var builder strings.Builder
 		builder.WriteString(config.Messages.Attachments)
-		for _, attachmentLink := range report.attachments {
+		for _, attachmentLink := range generalAttachments {
 			builder.WriteString("\n")
-			builder.WriteString(attachmentLink)
+			builder.WriteString(formatAttachmentLink(attachmentLink))
 		}
+		parts = append(parts, builder.String())
+	}
+
+	if logSummary := generateLogSummary(report); logSummary != "" {
+		parts = append(parts, config.Messages.LogSummary+logSummary)
 	}
 
-	builder.WriteString(strings.ReplaceAll(config.Messages.EndMessageReport, "{{USER_TAG}}", "<@"+userID+">"))
+	for _, field := range report.hookFields {
+		parts = append(parts, formatReportField(field))
+	}
 
-	result := builder.String()
-	return result, len(result) > config.ReportSafeMessageLength
+	return append(parts, strings.ReplaceAll(report.reportType.EndMessage, "{{USER_TAG}}", "<@"+userID+">"))
 }
 
-func startNewReportConversation(userID string, interactionButtonChannelID string) {
+// Checks whether the report fits in the configured output style
+func isReportTooLarge(report *reportData, userID string) bool {
+	if canPostOversizedReports() {
+		return false
+	}
+
+	if isEmbedOutput() {
+		_, tooLarge := generateReportEmbed(report, config.Messages.ReportEmbedTitle, userID)
+		return tooLarge
+	}
+
+	// The header and the lines that are added later on have to fit in the message as well
+	finalReport, tooLarge := generateFinalBugReport(report, false, false, userID)
+	headerLength := len(config.Messages.ReportHeader) + len(report.reportType.Name) + maxReportIDLength
+	return tooLarge || headerLength+len(finalReport) > getReportContentLimit()
+}
+
+// The report type ID is optional, when it's empty and there are multiple report types the user is asked to choose one first.
+// The returned message tells what happened, that way the slash command can reply with it.
+func startNewReportConversation(userID, interactionButtonChannelID, reportTypeID string) (response string) {
+	unableToDMMessage := strings.ReplaceAll(config.Messages.UnableToDMPerson, "{{USER_TAG}}", "<@"+userID+">")
+
+	chosenType := findReportType(reportTypeID)
+	if chosenType == nil && len(config.ReportTypes) == 1 {
+		chosenType = config.ReportTypes[0]
+	}
+
+	report := &reportData{
+		attachments:          make([]reportAttachment, 0),
+		currentQuestionIndex: 0,
+		lastInteraction:      time.Now(),
+		lock:                 new(sync.Mutex),
+		canEdit:              false,
+		canSubmit:            false,
+		hasReachedEnd:        false,
+		shouldReadAnswer:     false,
+		isInSubmitMenu:       false,
+		isChoosingType:       true,
+	}
+
+	// The report is claimed right away so a second report can't slip in, the Direct Messages are only sent after the
+	// global lock is released. Nobody else knows the report yet, so locking it here can't block.
 	currentReportsMutex.Lock()
-	defer currentReportsMutex.Unlock()
+	alreadyInProcess := isAlreadyInReportProcess(userID)
+	onCooldown := !alreadyInProcess && chosenType != nil && isUserOnReportCooldown(userID, chosenType.ID)
+	if !alreadyInProcess && !onCooldown {
+		report.lock.Lock()
+		currentOngoingReports[userID] = report
+	}
+	currentReportsMutex.Unlock()
 
-	if isAlreadyInReportProcess(userID) {
+	if alreadyInProcess {
 		// Adding the cooldown so the user can't spam! It's not completely fool proof due to multithreading, but that doesn't really matter
 		if setAndCheckCooldownForUserMessages(userID) {
-			return
+			return formatAlreadyCreatingReport()
 		}
 
-		baseString := config.Messages.AlreadyCreatingReport
-		baseString = strings.ReplaceAll(baseString, "((CANCEL_COMMAND}}", config.BotDMCommandPrefix+config.BotDMCommandCancel)
-		if !sendMessageToDM(baseString, userID) {
+		if !sendMessageToDM(formatAlreadyCreatingReport(), userID) {
 			sendDMFailedMessageIfNeeded(userID, interactionButtonChannelID)
 		}
-		return
+		return formatAlreadyCreatingReport()
 	}
 
-	if isUserOnReportCooldown(userID) {
+	if onCooldown {
 		if setAndCheckCooldownForUserMessages(userID) {
-			return
+			return config.Messages.ReportCooldown
 		}
 
 		if !sendMessageToDM(config.Messages.ReportCooldown, userID) {
 			sendDMFailedMessageIfNeeded(userID, interactionButtonChannelID)
 		}
-		return
+		return config.Messages.ReportCooldown
 	}
 
-	questions := make([]reportQuestionData, len(config.Questions))
-	for index, question := range config.Questions {
-		fixedFormats := make([]string, len(config.Questions[index].FixedAnswers))
+	defer report.lock.Unlock()
+
+	var succeeded bool
+	if chosenType != nil {
+		startReportQuestions(report, chosenType)
+		succeeded = sendReportQuestion(report, userID, true)
+	} else {
+		succeeded = sendReportTypePrompt(userID, true)
+	}
+
+	if !succeeded {
+		removeReportAndUserFromCache(userID, report)
+
+		// Set the user on a cooldown
+		if setAndCheckCooldownForUserMessages(userID) {
+			return unableToDMMessage
+		}
+
+		sendDMFailedMessageIfNeeded(userID, interactionButtonChannelID)
+		return unableToDMMessage
+	}
+
+	persistOngoingReport(userID, report)
+	fireOngoingReportEvent(reportEventStarted, userID, report)
+
+	return config.Messages.SlashReportStarted
+}
+
+// Older configs have a typo in the placeholder, those still work as well
+func formatAlreadyCreatingReport() string {
+	cancelCommand := config.BotDMCommandPrefix + config.BotDMCommandCancel
+	baseString := strings.ReplaceAll(config.Messages.AlreadyCreatingReport, "{{CANCEL_COMMAND}}", cancelCommand)
+	return strings.ReplaceAll(baseString, "((CANCEL_COMMAND}}", cancelCommand)
+}
+
+func buildReportQuestions(chosenType *reportType) []reportQuestionData {
+	questions := make([]reportQuestionData, len(chosenType.Questions))
+	for index, question := range chosenType.Questions {
+		fixedFormats := make([]string, len(question.FixedAnswers))
 		for fixedIndex, fixedAnswer := range question.FixedAnswers {
 			fixedFormats[fixedIndex] = strings.ToLower(fixedAnswer)
 		}
@@ -335,30 +741,7 @@ func startNewReportConversation(userID string, interactionButtonChannelID string
 		}
 	}
 
-	report := &reportData{
-		attachments:          make([]string, 0),
-		currentQuestionIndex: 0,
-		lastInteraction:      time.Now(),
-		data:                 questions,
-		lock:                 new(sync.Mutex),
-		canEdit:              false,
-		canSubmit:            false,
-		hasReachedEnd:        false,
-		shouldReadAnswer:     true,
-		isInSubmitMenu:       false,
-	}
-
-	if !sendReportQuestion(report, userID, true) {
-		// Set the user on a cooldown
-		if setAndCheckCooldownForUserMessages(userID) {
-			return
-		}
-
-		sendDMFailedMessageIfNeeded(userID, interactionButtonChannelID)
-		return
-	}
-
-	currentOngoingReports[userID] = report
+	return questions
 }
 
 // If we can't create a report and the channel ID on which a person possibly clicked isn't empty
@@ -390,12 +773,23 @@ func sendReportQuestion(report *reportData, userID string, firstMessage bool) (s
 	formattedFirstQuestion := ""
 
 	if firstMessage {
-		formattedFirstQuestion = strings.ReplaceAll(config.Messages.WelcomeMessage, "{{REPORT_TIMEOUT}}", strconv.Itoa(int(config.ReportTimeoutMinutes))) + "\n\n"
-		formattedFirstQuestion = strings.ReplaceAll(formattedFirstQuestion, "{{CANCEL_COMMAND}}", config.BotDMCommandPrefix+config.BotDMCommandCancel)
+		formattedFirstQuestion = formatWelcomeMessage()
 	}
 
 	formattedFirstQuestion += report.data[report.currentQuestionIndex].question.Question
-	return sendMessageToDM(formattedFirstQuestion, userID)
+	if report.data[report.currentQuestionIndex].question.Optional {
+		formattedFirstQuestion += strings.ReplaceAll(config.Messages.OptionalQuestionHint, "{{SKIP_COMMAND}}", config.BotDMCommandPrefix+config.BotDMCommandSkip)
+	}
+
+	return sendComplexMessageToDM(&discordgo.MessageSend{
+		Content:    formattedFirstQuestion,
+		Components: generateQuestionComponents(report),
+	}, userID)
+}
+
+func formatWelcomeMessage() string {
+	welcomeMessage := strings.ReplaceAll(config.Messages.WelcomeMessage, "{{REPORT_TIMEOUT}}", strconv.Itoa(int(config.ReportTimeoutMinutes))) + "\n\n"
+	return strings.ReplaceAll(welcomeMessage, "{{CANCEL_COMMAND}}", config.BotDMCommandPrefix+config.BotDMCommandCancel)
 }
 
 func sendMessageToDM(content, userID string) (succeeded bool) {
@@ -408,6 +802,16 @@ func sendMessageToDM(content, userID string) (succeeded bool) {
 	return messageErr == nil
 }
 
+func sendComplexMessageToDM(message *discordgo.MessageSend, userID string) (succeeded bool) {
+	channel, channelErr := getUserChannel(userID)
+	if channelErr != nil {
+		return false
+	}
+
+	_, messageErr := botSession.ChannelMessageSendComplex(channel.ID, message)
+	return messageErr == nil
+}
+
 func getUserChannel(userID string) (channel *discordgo.Channel, err error) {
 	return botSession.UserChannelCreate(userID)
 }
@@ -419,17 +823,42 @@ type basicConfig struct {
 	BotDMCommandSubmit string `json:"bot_dm_command_submit"`
 	BotDMCommandEdit   string `json:"bot_dm_command_edit"`
 	BotDMCommandCancel string `json:"bot_dm_command_cancel"`
-
-	SubmitReportChannelID            string           `json:"submit_report_channel_id"`
-	ReportChannelID                  string           `json:"report_channel_id"`
-	Questions                        []reportQuestion `json:"questions"`
-	ReportTimeoutMinutes             uint             `json:"report_timeout_minutes"`
-	ReportMaxAttachments             uint             `json:"report_max_attachments"`
-	RemoveButtonMessagesAfterSeconds uint             `json:"remove_button_messages_after_seconds"`
-	ReportMessagesCooldownSeconds    uint             `json:"report_messages_cooldown_seconds"`
-	ReportCooldownMinutes            uint             `json:"report_cooldown_minutes"`
-	ReportSafeMessageLength          int              `json:"message_safe_length"`
-
+	BotDMCommandSkip   string `json:"bot_dm_command_skip"`
+
+	BotDMCommandAttachments      string `json:"bot_dm_command_attachments"`
+	BotDMCommandRemoveAttachment string `json:"bot_dm_command_remove_attachment"`
+
+	BotStaffCommandStatus string `json:"bot_staff_command_status"`
+
+	GuildID                          string                  `json:"guild_id"`
+	SubmitReportChannelID            string                  `json:"submit_report_channel_id"`
+	ReportChannelID                  string                  `json:"report_channel_id"`
+	Questions                        []reportQuestion        `json:"questions"`
+	ReportTypes                      []*reportType           `json:"report_types"`
+	ReportTimeoutMinutes             uint                    `json:"report_timeout_minutes"`
+	ReportMaxAttachments             uint                    `json:"report_max_attachments"`
+	RemoveButtonMessagesAfterSeconds uint                    `json:"remove_button_messages_after_seconds"`
+	ReportMessagesCooldownSeconds    uint                    `json:"report_messages_cooldown_seconds"`
+	ReportCooldownMinutes            uint                    `json:"report_cooldown_minutes"`
+	ReportSafeMessageLength          int                     `json:"message_safe_length"`
+	UseModalsForShortReports         bool                    `json:"use_modals_for_short_reports"`
+	ReportOutputStyle                string                  `json:"report_output_style"`
+	OversizedReports                 string                  `json:"oversized_reports"`
+	CreateReportThreads              bool                    `json:"create_report_threads"`
+	StaffRelayPrefix                 string                  `json:"staff_relay_prefix"`
+	RelayTimeoutMinutes              uint                    `json:"relay_timeout_minutes"`
+	AttachmentStorage                attachmentStorageConfig `json:"attachment_storage"`
+	AttachmentPolicy                 attachmentPolicyConfig  `json:"attachment_policy"`
+	LogFileExtensions                []string                `json:"log_file_extensions"`
+	LogExtractors                    []logExtractor          `json:"log_extractors"`
+	IssueTracker                     issueTrackerConfig      `json:"issue_tracker"`
+	TrackerWebhooks                  trackerWebhooksConfig   `json:"tracker_webhooks"`
+	OutboundWebhooks                 outboundWebhooksConfig  `json:"outbound_webhooks"`
+	SubmissionHook                   submissionHookConfig    `json:"submission_hook"`
+	StaffRoleIDs                     []string                `json:"staff_role_ids"`
+	UseMessageContentIntent          bool                    `json:"use_message_content_intent"`
+
+	Storage  storageConfig      `json:"storage"`
 	Messages messagesDataConfig `json:"messages_data"`
 }
 
@@ -439,8 +868,22 @@ type messagesDataConfig struct {
 	InactiveReport               string `json:"report_timeout"`
 	AlreadyCreatingReport        string `json:"already_creating_report"`
 	SuccessfullySubmittedReport  string `json:"thanks_for_submitting_a_report"`
+	SubmittedReportWithoutID     string `json:"thanks_for_submitting_a_report_without_id"`
 	ReachedMaxAttachments        string `json:"reached_max_attachments"`
 	Attachments                  string `json:"attachments"`
+	AttachmentTypeNotAllowed     string `json:"attachment_type_not_allowed"`
+	AttachmentTooLarge           string `json:"attachment_too_large"`
+	AttachmentsTotalTooLarge     string `json:"attachments_total_too_large"`
+	ScreenshotRequired           string `json:"screenshot_required"`
+	AttachmentsListHeader        string `json:"attachments_list_header"`
+	NoAttachments                string `json:"no_attachments"`
+	AttachmentRemoved            string `json:"attachment_removed"`
+	InvalidAttachmentNumber      string `json:"invalid_attachment_number"`
+	LogSummary                   string `json:"log_summary"`
+	ReportField                  string `json:"report_field"`
+	ReportVetoed                 string `json:"report_vetoed"`
+	ReportPostFailed             string `json:"report_post_failed"`
+	IssueLinkLine                string `json:"issue_link_line"`
 	AttachmentUploaded           string `json:"attachment_uploaded_with_report"`
 	AttachmentUploadedPlural     string `json:"attachment_uploaded_with_report_plural"`
 	EndMessageReport             string `json:"end_message_report"`
@@ -453,15 +896,53 @@ type messagesDataConfig struct {
 	InteractionButtonContent     string `json:"interaction_button_content"`
 	UnableToDMPerson             string `json:"unable_to_dm_person"`
 	WelcomeMessage               string `json:"welcome_message"`
+	ReportRestored               string `json:"report_restored"`
+	ReportNotRestored            string `json:"report_not_restored"`
+	ReportHeader                 string `json:"report_header"`
+	ReportStatusLine             string `json:"report_status_line"`
+	ReportStatusLineTracker      string `json:"report_status_line_tracker"`
+	ReportNotFound               string `json:"report_not_found"`
+	InvalidStatusCommand         string `json:"invalid_status_command"`
+	StaffNote                    string `json:"staff_note"`
+	OptionalQuestionHint         string `json:"optional_question_hint"`
+	CantSkipQuestion             string `json:"cant_skip_question"`
+	SkippedAnswer                string `json:"skipped_answer"`
+	ChooseReportType             string `json:"choose_report_type"`
+	InvalidReportType            string `json:"invalid_report_type"`
+	ReportTypeSelectPlaceholder  string `json:"report_type_select_placeholder"`
+	SlashReportStarted           string `json:"slash_report_started"`
+	NoOngoingReport              string `json:"no_ongoing_report"`
+	NoSubmittedReports           string `json:"no_submitted_reports"`
+	ReportStatusOverview         string `json:"report_status_overview"`
+	MyReportsHeader              string `json:"my_reports_header"`
+	MyReportsLine                string `json:"my_reports_line"`
+	ModalMovedToDM               string `json:"modal_moved_to_dm"`
+	ModalContinueInDM            string `json:"modal_continue_in_dm"`
+	AnswerSelectPlaceholder      string `json:"answer_select_placeholder"`
+	EditSelectPlaceholder        string `json:"edit_select_placeholder"`
+	ReportEmbedTitle             string `json:"report_embed_title"`
+	ReportAttachedAsFile         string `json:"report_attached_as_file"`
+	ReportThreadTitle            string `json:"report_thread_title"`
+	RelayToReporter              string `json:"relay_to_reporter"`
+	RelayToReport                string `json:"relay_to_report"`
+	RelayAskedByStaff            string `json:"relay_asked_by_staff"`
+	RelaySent                    string `json:"relay_sent"`
+	RelayFailed                  string `json:"relay_failed"`
+	RelayEnded                   string `json:"relay_ended"`
+
+	StatusChangedNotifications map[reportStatus]string `json:"status_changed_notifications"`
 }
 
 type reportData struct {
 	currentQuestionIndex uint
 	lastInteraction      time.Time
 	data                 []reportQuestionData
-	attachments          []string
+	attachments          []reportAttachment
 	lock                 *sync.Mutex
+	reportType           *reportType
+	hookFields           []reportField
 
+	isChoosingType   bool
 	isInSubmitMenu   bool
 	canSubmit        bool
 	canEdit          bool
@@ -471,13 +952,24 @@ type reportData struct {
 
 type reportQuestionData struct {
 	answer   string
+	skipped  bool
 	question reportQuestionFormatted
 }
 
 type reportQuestion struct {
-	Question     string   `json:"question"`
-	PrettyFormat string   `json:"pretty_format"`
-	FixedAnswers []string `json:"fixed_answers,omitempty"`
+	ID              string              `json:"id,omitempty"`
+	Question        string              `json:"question"`
+	PrettyFormat    string              `json:"pretty_format"`
+	FixedAnswers    []string            `json:"fixed_answers,omitempty"`
+	Conditions      []questionCondition `json:"conditions,omitempty"`
+	Validators      []answerValidator   `json:"validators,omitempty"`
+	Optional        bool                `json:"optional,omitempty"`
+	AnswerColors    map[string]string   `json:"answer_colors,omitempty"`
+	ForumTags       map[string]string   `json:"forum_tags,omitempty"`
+	IssueLabels     map[string]string   `json:"issue_labels,omitempty"`
+	IssueComponents map[string]string   `json:"issue_components,omitempty"`
+
+	answerColorValues map[string]int
 }
 
 type reportQuestionFormatted struct {
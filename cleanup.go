@@ -12,6 +12,7 @@ func startCleanupTimer() {
 		go checkOnGoingMessagesCooldown(currentTime)
 		go checkOngoingReportCooldowns(currentTime)
 		go checkOngoingReportCleanup(currentTime)
+		go checkRelayConversationCleanup(currentTime)
 	}
 }
 
@@ -45,19 +46,28 @@ func checkOngoingReportCooldowns(currentTime time.Time) {
 
 func checkOngoingReportCleanup(currentTime time.Time) {
 	currentReportsMutex.Lock()
-	defer currentReportsMutex.Unlock()
 
-	markedForRemoval := make([]string, 0)
+	markedForRemoval := make(map[string]*reportData)
 
 	for userID, report := range currentOngoingReports {
 		// If this validates true that means the last interaction with the user has been larger than our timeout
 		if currentTime.After(report.lastInteraction.Add(time.Duration(config.ReportTimeoutMinutes) * time.Minute)) {
-			markedForRemoval = append(markedForRemoval, userID)
+			markedForRemoval[userID] = report
 		}
 	}
 
-	for _, userID := range markedForRemoval {
+	for userID := range markedForRemoval {
 		delete(currentOngoingReports, userID)
+	}
+	currentReportsMutex.Unlock()
+
+	// The webhooks, the storage and Discord are only contacted after the lock is released, otherwise every report would have to wait
+	for userID, report := range markedForRemoval {
+		report.lock.Lock()
+		fireOngoingReportEvent(reportEventTimedOut, userID, report)
+		report.lock.Unlock()
+
+		removeOngoingReportFromStorage(userID)
 		sendMessageToDM(config.Messages.InactiveReport, userID)
 	}
 }
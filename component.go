@@ -1,31 +1,82 @@
 package main
 
 import (
+	"strings"
+
 	"github.com/bwmarrin/discordgo"
 )
 
 const (
-	bugReportButtonID = "report_btn"
+	bugReportButtonID  = "report_btn"
+	reportTypeSelectID = "report_type_select"
 )
 
 // This takes care of the slash command and interactions for the button that can be setup
 func handleInteractions(session *discordgo.Session, interaction *discordgo.InteractionCreate) {
 	switch interaction.Type {
+	case discordgo.InteractionApplicationCommand:
+		handleSlashCommand(session, interaction)
+	case discordgo.InteractionModalSubmit:
+		if strings.HasPrefix(interaction.ModalSubmitData().CustomID, askReporterModalPrefix) {
+			handleAskReporterModalSubmit(session, interaction)
+			return
+		}
+
+		handleReportModalSubmit(session, interaction)
 	case discordgo.InteractionMessageComponent:
-		if interaction.MessageComponentData().CustomID != bugReportButtonID {
+		customID := interaction.MessageComponentData().CustomID
+		if strings.HasPrefix(customID, reportStatusButtonPrefix) {
+			handleTriageButton(session, interaction, customID)
 			return
 		}
 
-		// From here we should always respond to Discord that we at least received the event and handled it accordingly
-		defer session.InteractionRespond(interaction.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseDeferredMessageUpdate,
-		})
+		if strings.HasPrefix(customID, askReporterButtonPrefix) {
+			handleAskReporterButton(session, interaction, customID)
+			return
+		}
+
+		if strings.HasPrefix(customID, dmComponentPrefix) {
+			handleDMComponent(session, interaction, customID)
+			return
+		}
+
+		if customID != bugReportButtonID && customID != reportTypeSelectID {
+			return
+		}
 
 		if interaction.Member == nil {
+			session.InteractionRespond(interaction.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseDeferredMessageUpdate,
+			})
+			return
+		}
+
+		// Someone picked the type of report straight away from the select menu
+		reportTypeID := ""
+		if customID == reportTypeSelectID && len(interaction.MessageComponentData().Values) > 0 {
+			reportTypeID = interaction.MessageComponentData().Values[0]
+		}
+
+		// Short reports are filled in with a modal, opening it is our response to Discord
+		if tryOpenReportModal(session, interaction, interaction.Member.User.ID, reportTypeID) {
 			return
 		}
 
+		// From here we should always respond to Discord that we at least received the event and handled it accordingly
+		defer session.InteractionRespond(interaction.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredMessageUpdate,
+		})
+
 		// Handle the bug button click!
-		go startNewReportConversation(interaction.Member.User.ID, interaction.ChannelID)
+		go startNewReportConversation(interaction.Member.User.ID, interaction.ChannelID, reportTypeID)
 	}
 }
+
+// Members are filled in a guild, users in Direct Messages
+func getInteractionUser(interaction *discordgo.InteractionCreate) *discordgo.User {
+	if interaction.Member != nil {
+		return interaction.Member.User
+	}
+
+	return interaction.User
+}
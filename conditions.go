@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+// Checks whether a question should be asked based on the answers of the earlier questions.
+// A question is only active when all of its conditions are met, a condition can only look at earlier questions
+// and those earlier questions have to be active themselves, otherwise their (old) answer doesn't count.
+func isQuestionActive(report *reportData, index int) bool {
+	for _, condition := range report.data[index].question.Conditions {
+		referencedIndex := findQuestionIndexByID(report, condition.QuestionID, index)
+		if referencedIndex == -1 || !isQuestionActive(report, referencedIndex) {
+			return false
+		}
+
+		if !condition.isMetBy(report.data[referencedIndex].answer) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Returns the index of the next question that should be asked after the given index.
+// When onlyUnanswered is true, questions that already have an answer are skipped as well.
+func findNextQuestionIndex(report *reportData, afterIndex int, onlyUnanswered bool) (index int, found bool) {
+	for index = afterIndex + 1; index < len(report.data); index++ {
+		if onlyUnanswered && (report.data[index].answer != "" || report.data[index].skipped) {
+			continue
+		}
+
+		if isQuestionActive(report, index) {
+			return index, true
+		}
+	}
+
+	return -1, false
+}
+
+// Only questions before the given index are searched, this way conditions can never loop
+func findQuestionIndexByID(report *reportData, questionID string, beforeIndex int) int {
+	for index := 0; index < beforeIndex; index++ {
+		if report.data[index].question.ID != "" && report.data[index].question.ID == questionID {
+			return index
+		}
+	}
+
+	return -1
+}
+
+// A condition that points to a question that doesn't exist, or isn't asked before it, can never be met.
+// The question would silently never be asked, so those mistakes are caught when the config is loaded.
+func prepareQuestionConditions(chosenType *reportType) error {
+	seenIDs := make(map[string]bool)
+	for index, question := range chosenType.Questions {
+		for _, condition := range question.Conditions {
+			referencedIndex := findConfigQuestionIndex(chosenType, condition.QuestionID)
+			if referencedIndex == -1 {
+				return errors.New("a condition of report type \"" + chosenType.ID + "\" points to unknown question \"" + condition.QuestionID + "\"")
+			}
+			if referencedIndex >= index {
+				return errors.New("a condition of report type \"" + chosenType.ID + "\" points to question \"" + condition.QuestionID + "\", which isn't asked before it")
+			}
+		}
+
+		if question.ID == "" {
+			continue
+		}
+		if seenIDs[question.ID] {
+			return errors.New("question id \"" + question.ID + "\" is used more than once in report type \"" + chosenType.ID + "\"")
+		}
+		seenIDs[question.ID] = true
+	}
+
+	if chosenType.TitleQuestionID != "" && findConfigQuestionIndex(chosenType, chosenType.TitleQuestionID) == -1 {
+		return errors.New("the title question of report type \"" + chosenType.ID + "\" points to unknown question \"" + chosenType.TitleQuestionID + "\"")
+	}
+
+	return nil
+}
+
+func findConfigQuestionIndex(chosenType *reportType, questionID string) int {
+	for index, question := range chosenType.Questions {
+		if question.ID != "" && question.ID == questionID {
+			return index
+		}
+	}
+
+	return -1
+}
+
+func (condition questionCondition) isMetBy(answer string) bool {
+	formattedAnswer := strings.ToLower(answer)
+
+	if len(condition.AnyOf) > 0 && !containsIgnoreCase(condition.AnyOf, formattedAnswer) {
+		return false
+	}
+
+	return !containsIgnoreCase(condition.NoneOf, formattedAnswer)
+}
+
+func containsIgnoreCase(values []string, formattedValue string) bool {
+	for _, value := range values {
+		if strings.ToLower(value) == formattedValue {
+			return true
+		}
+	}
+
+	return false
+}
+
+type questionCondition struct {
+	QuestionID string   `json:"question_id"`
+	AnyOf      []string `json:"any_of,omitempty"`
+	NoneOf     []string `json:"none_of,omitempty"`
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	dmComponentPrefix     = "dm_"
+	dmAnswerSelectPrefix  = "dm_answer:"
+	dmSkipButtonPrefix    = "dm_skip:"
+	dmSubmitButtonID      = "dm_submit"
+	dmCancelButtonID      = "dm_cancel"
+	dmEditSelectID        = "dm_edit"
+	maxSelectMenuOptions  = 25
+	maxSelectOptionLength = 100
+)
+
+// Generates the select menu for fixed answers and the skip button for optional questions.
+// The index of the question is part of the custom ID so old menus can't answer a different question.
+// Fixed answers can be longer than Discord allows for an option value, so the option values are the answer indexes.
+func generateQuestionComponents(report *reportData) []discordgo.MessageComponent {
+	question := report.data[report.currentQuestionIndex].question
+	questionIndex := strconv.Itoa(int(report.currentQuestionIndex))
+	components := make([]discordgo.MessageComponent, 0, 2)
+
+	if len(question.FixedAnswers) > 0 && len(question.FixedAnswers) <= maxSelectMenuOptions {
+		options := make([]discordgo.SelectMenuOption, len(question.FixedAnswers))
+		for index, fixedAnswer := range question.FixedAnswers {
+			options[index] = discordgo.SelectMenuOption{
+				Label: truncateText(fixedAnswer, maxSelectOptionLength),
+				Value: strconv.Itoa(index),
+			}
+		}
+
+		components = append(components, discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					CustomID:    dmAnswerSelectPrefix + questionIndex,
+					Placeholder: config.Messages.AnswerSelectPlaceholder,
+					Options:     options,
+				},
+			},
+		})
+	}
+
+	if question.Optional {
+		components = append(components, discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					CustomID: dmSkipButtonPrefix + questionIndex,
+					Label:    "Skip",
+					Style:    discordgo.SecondaryButton,
+				},
+			},
+		})
+	}
+
+	return components
+}
+
+func generateSubmitMenuComponents(report *reportData) []discordgo.MessageComponent {
+	options := make([]discordgo.SelectMenuOption, 0, len(report.data))
+	for index, value := range report.data {
+		if !isQuestionActive(report, index) || len(options) == maxSelectMenuOptions {
+			continue
+		}
+
+		options = append(options, discordgo.SelectMenuOption{
+			Label: truncateText("#"+strconv.Itoa(index+1)+" "+formatQuestionLabel(value.question.reportQuestion), maxSelectOptionLength),
+			Value: strconv.Itoa(index + 1),
+		})
+	}
+
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					CustomID: dmSubmitButtonID,
+					Label:    "Submit",
+					Style:    discordgo.SuccessButton,
+					Disabled: !report.canSubmit,
+				},
+				discordgo.Button{
+					CustomID: dmCancelButtonID,
+					Label:    "Cancel",
+					Style:    discordgo.DangerButton,
+				},
+			},
+		},
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					CustomID:    dmEditSelectID,
+					Placeholder: config.Messages.EditSelectPlaceholder,
+					Options:     options,
+				},
+			},
+		},
+	}
+}
+
+// Every button and select menu in the Direct Messages is turned into the command or answer the user could have typed,
+// that way the conversation only has one path to go through.
+func handleDMComponent(session *discordgo.Session, interaction *discordgo.InteractionCreate, customID string) {
+	user := getInteractionUser(interaction)
+	if user == nil {
+		return
+	}
+
+	// The components are removed once they're used, otherwise they could be used again at the wrong moment
+	session.InteractionRespond(interaction.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    interaction.Message.Content,
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+
+	currentReportsMutex.RLock()
+	report, ok := currentOngoingReports[user.ID]
+	currentReportsMutex.RUnlock()
+	if !ok {
+		return
+	}
+
+	report.lock.Lock()
+	defer report.lock.Unlock()
+
+	content, valid := translateDMComponent(report, customID, interaction.MessageComponentData().Values)
+	if !valid {
+		return
+	}
+
+	continueOngoingReport(report, content, user.ID, nil, nil)
+	persistOngoingReport(user.ID, report)
+}
+
+func translateDMComponent(report *reportData, customID string, values []string) (content string, valid bool) {
+	switch {
+	case strings.HasPrefix(customID, dmAnswerSelectPrefix):
+		if len(values) == 0 || !isCurrentQuestionComponent(report, strings.TrimPrefix(customID, dmAnswerSelectPrefix)) {
+			return "", false
+		}
+		fixedAnswers := report.data[report.currentQuestionIndex].question.FixedAnswers
+		answerIndex, err := strconv.Atoi(values[0])
+		if err != nil || answerIndex < 0 || answerIndex >= len(fixedAnswers) {
+			return "", false
+		}
+		return fixedAnswers[answerIndex], true
+	case strings.HasPrefix(customID, dmSkipButtonPrefix):
+		if !isCurrentQuestionComponent(report, strings.TrimPrefix(customID, dmSkipButtonPrefix)) {
+			return "", false
+		}
+		return config.BotDMCommandPrefix + config.BotDMCommandSkip, true
+	case customID == dmSubmitButtonID:
+		return config.BotDMCommandPrefix + config.BotDMCommandSubmit, report.isInSubmitMenu
+	case customID == dmCancelButtonID:
+		return config.BotDMCommandPrefix + config.BotDMCommandCancel, true
+	case customID == dmEditSelectID:
+		if len(values) == 0 {
+			return "", false
+		}
+		return config.BotDMCommandPrefix + config.BotDMCommandEdit + " " + values[0], report.isInSubmitMenu
+	}
+
+	return "", false
+}
+
+func isCurrentQuestionComponent(report *reportData, questionIndex string) bool {
+	return report.shouldReadAnswer && !report.isChoosingType && questionIndex == strconv.Itoa(int(report.currentQuestionIndex))
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"errors"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	reportOutputText  = "text"
+	reportOutputEmbed = "embed"
+
+	maxEmbedTitleLength       = 256
+	maxEmbedDescriptionLength = 4096
+	maxEmbedFields            = 25
+	maxEmbedFieldNameLength   = 256
+	maxEmbedFieldValueLength  = 1024
+	maxEmbedTotalLength       = 6000
+
+	// Discord doesn't allow empty field names or values, a zero width space is the way around that
+	emptyEmbedField = "\u200b"
+)
+
+var imageExtensions = []string{".png", ".jpg", ".jpeg", ".gif", ".webp"}
+
+func isEmbedOutput() bool {
+	return config.ReportOutputStyle == reportOutputEmbed
+}
+
+// Renders the report as an embed, every question becomes a field. Answers that don't fit in a single field
+// are continued in the next one, only when the limits of the whole embed are reached the report is too large.
+func generateReportEmbed(report *reportData, title, userID string) (embed *discordgo.MessageEmbed, tooLarge bool) {
+	embed = &discordgo.MessageEmbed{
+		Title:       truncateText(title, maxEmbedTitleLength),
+		Description: strings.TrimSpace(strings.ReplaceAll(report.reportType.EndMessage, "{{USER_TAG}}", "<@"+userID+">")),
+		Color:       getReportColor(report),
+		Fields:      make([]*discordgo.MessageEmbedField, 0, len(report.data)),
+	}
+
+	for index, value := range report.data {
+		if !isQuestionShown(report, index, false) {
+			continue
+		}
+
+		answer := value.answer
+		if value.skipped {
+			answer = config.Messages.SkippedAnswer
+		}
+
+		for _, attachmentLink := range getQuestionAttachmentLinks(report, index) {
+			answer += "\n" + formatAttachmentLink(attachmentLink)
+		}
+
+		embed.Fields = append(embed.Fields, splitEmbedField(formatQuestionLabel(value.question.reportQuestion), answer)...)
+	}
+
+	if generalAttachments := getGeneralAttachmentLinks(report, false); len(generalAttachments) > 0 {
+		for index, attachmentLink := range generalAttachments {
+			generalAttachments[index] = formatAttachmentLink(attachmentLink)
+		}
+		embed.Fields = append(embed.Fields, splitEmbedField(formatSectionLabel(config.Messages.Attachments), strings.Join(generalAttachments, "\n"))...)
+	}
+
+	if logSummary := generateLogSummary(report); logSummary != "" {
+		embed.Fields = append(embed.Fields, splitEmbedField(formatSectionLabel(config.Messages.LogSummary), logSummary)...)
+	}
+
+	for _, field := range report.hookFields {
+		embed.Fields = append(embed.Fields, splitEmbedField(field.Name, field.Value)...)
+	}
+
+	for _, attachment := range report.attachments {
+		if isImageLink(attachment.link) {
+			embed.Image = &discordgo.MessageEmbedImage{URL: attachment.link}
+			break
+		}
+	}
+
+	// The name of the author counts towards the total length as well, so it's set before the length is checked
+	setEmbedAuthor(embed, userID)
+
+	return embed, len(embed.Fields) > maxEmbedFields || getEmbedLength(embed) > maxEmbedTotalLength || utf8.RuneCountInString(embed.Description) > maxEmbedDescriptionLength
+}
+
+// The reporter is shown as the author of the embed, this is only done when posting since it needs an extra request
+func setEmbedAuthor(embed *discordgo.MessageEmbed, userID string) {
+	user, userErr := botSession.User(userID)
+	if userErr != nil {
+		return
+	}
+
+	embed.Author = &discordgo.MessageEmbedAuthor{
+		Name:    user.Username,
+		IconURL: user.AvatarURL(""),
+	}
+}
+
+func splitEmbedField(name, value string) []*discordgo.MessageEmbedField {
+	name = truncateText(name, maxEmbedFieldNameLength)
+	if name == "" {
+		name = emptyEmbedField
+	}
+
+	if strings.TrimSpace(value) == "" {
+		return []*discordgo.MessageEmbedField{{Name: name, Value: emptyEmbedField}}
+	}
+
+	fields := make([]*discordgo.MessageEmbedField, 0, 1)
+	runes := []rune(value)
+	for len(runes) > 0 {
+		end := len(runes)
+		if end > maxEmbedFieldValueLength {
+			end = maxEmbedFieldValueLength
+		}
+
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  name,
+			Value: string(runes[:end]),
+		})
+
+		runes = runes[end:]
+		name = emptyEmbedField
+	}
+
+	return fields
+}
+
+func getEmbedLength(embed *discordgo.MessageEmbed) int {
+	length := utf8.RuneCountInString(embed.Title) + utf8.RuneCountInString(embed.Description)
+	for _, field := range embed.Fields {
+		length += utf8.RuneCountInString(field.Name) + utf8.RuneCountInString(field.Value)
+	}
+
+	if embed.Author != nil {
+		length += utf8.RuneCountInString(embed.Author.Name)
+	}
+
+	return length
+}
+
+// A fixed answer with a colour wins from the colour of the report type, the first question with one decides
+func getReportColor(report *reportData) int {
+	for index, value := range report.data {
+		if len(value.question.answerColorValues) == 0 || !isQuestionActive(report, index) {
+			continue
+		}
+
+		if color, ok := value.question.answerColorValues[strings.ToLower(value.answer)]; ok {
+			return color
+		}
+	}
+
+	return report.reportType.colorValue
+}
+
+// Colours are written as hex in the config, for example "#5865F2"
+func parseColor(color string) (int, error) {
+	if color == "" {
+		return 0, nil
+	}
+
+	value, parseErr := strconv.ParseInt(strings.TrimPrefix(color, "#"), 16, 32)
+	if parseErr != nil || value < 0 || value > 0xFFFFFF {
+		return 0, errors.New("invalid colour \"" + color + "\", please use a hex colour like \"#5865F2\"")
+	}
+
+	return int(value), nil
+}
+
+func prepareQuestionColors(questions []reportQuestion) error {
+	for index := range questions {
+		if len(questions[index].AnswerColors) == 0 {
+			continue
+		}
+
+		questions[index].answerColorValues = make(map[string]int, len(questions[index].AnswerColors))
+		for answer, color := range questions[index].AnswerColors {
+			colorValue, colorErr := parseColor(color)
+			if colorErr != nil {
+				return colorErr
+			}
+			questions[index].answerColorValues[strings.ToLower(answer)] = colorValue
+		}
+	}
+
+	return nil
+}
+
+// Attachment links usually end with query parameters, so only the path is checked
+func isImageLink(link string) bool {
+	extension := strings.ToLower(path.Ext(strings.SplitN(link, "?", 2)[0]))
+	for _, imageExtension := range imageExtensions {
+		if extension == imageExtension {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Section headers like the attachments are written as markdown for the text report, fields have their own styling
+func formatSectionLabel(header string) string {
+	return strings.TrimSpace(strings.Trim(strings.TrimSpace(header), "*_:"))
+}
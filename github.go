@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const defaultGitHubAPIBaseURL = "https://api.github.com"
+
+type gitHubIssueTracker struct {
+	apiBaseURL string
+	token      string
+	repository string
+}
+
+func newGitHubIssueTracker(trackerConfig issueTrackerConfig) *gitHubIssueTracker {
+	apiBaseURL := trackerConfig.APIBaseURL
+	if apiBaseURL == "" {
+		apiBaseURL = defaultGitHubAPIBaseURL
+	}
+
+	return &gitHubIssueTracker{
+		apiBaseURL: strings.TrimSuffix(apiBaseURL, "/"),
+		token:      trackerConfig.Token,
+		repository: trackerConfig.Repository,
+	}
+}
+
+// The repository is written as "owner/name"
+func (tracker *gitHubIssueTracker) createIssue(issue *trackerIssue) (*trackerIssueReference, error) {
+	payload := map[string]interface{}{
+		"title":  issue.title,
+		"body":   issue.body,
+		"labels": issue.labels,
+	}
+
+	var response struct {
+		Number  int    `json:"number"`
+		HTMLURL string `json:"html_url"`
+	}
+
+	requestErr := sendTrackerRequest(http.MethodPost, tracker.apiBaseURL+"/repos/"+tracker.repository+"/issues", map[string]string{
+		"Authorization":        "Bearer " + tracker.token,
+		"Accept":               "application/vnd.github+json",
+		"X-GitHub-Api-Version": "2022-11-28",
+	}, payload, &response)
+	if requestErr != nil {
+		return nil, requestErr
+	}
+
+	return &trackerIssueReference{
+		ID:  strconv.Itoa(response.Number),
+		URL: response.HTMLURL,
+	}, nil
+}
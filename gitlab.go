@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const defaultGitLabAPIBaseURL = "https://gitlab.com/api/v4"
+
+type gitLabIssueTracker struct {
+	apiBaseURL string
+	token      string
+	project    string
+}
+
+func newGitLabIssueTracker(trackerConfig issueTrackerConfig) *gitLabIssueTracker {
+	apiBaseURL := trackerConfig.APIBaseURL
+	if apiBaseURL == "" {
+		apiBaseURL = defaultGitLabAPIBaseURL
+	}
+
+	return &gitLabIssueTracker{
+		apiBaseURL: strings.TrimSuffix(apiBaseURL, "/"),
+		token:      trackerConfig.Token,
+		project:    trackerConfig.Repository,
+	}
+}
+
+// The project can be its numeric ID or its full path, for example "group/project"
+func (tracker *gitLabIssueTracker) createIssue(issue *trackerIssue) (*trackerIssueReference, error) {
+	payload := map[string]interface{}{
+		"title":       issue.title,
+		"description": issue.body,
+		"labels":      strings.Join(issue.labels, ","),
+	}
+
+	var response struct {
+		IID    int    `json:"iid"`
+		WebURL string `json:"web_url"`
+	}
+
+	requestErr := sendTrackerRequest(http.MethodPost, tracker.apiBaseURL+"/projects/"+url.PathEscape(tracker.project)+"/issues", map[string]string{
+		"PRIVATE-TOKEN": tracker.token,
+	}, payload, &response)
+	if requestErr != nil {
+		return nil, requestErr
+	}
+
+	return &trackerIssueReference{
+		ID:  strconv.Itoa(response.IID),
+		URL: response.WebURL,
+	}, nil
+}
@@ -3,7 +3,8 @@ module github.com/thomdemeulder/bugreportbot
 go 1.16
 
 require (
-	github.com/bwmarrin/discordgo v0.23.3-0.20210811014036-f7454d039f3a
+	github.com/bwmarrin/discordgo v0.28.1
+	go.etcd.io/bbolt v1.3.6
 	golang.org/x/crypto v0.0.0-20210711020723-a769d52b0f97 // indirect
 	golang.org/x/sys v0.0.0-20210809222454-d867a43fc93e // indirect
 )
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	issueTrackerGitHub = "github"
+	issueTrackerGitLab = "gitlab"
+	issueTrackerJira   = "jira"
+
+	createIssueOnSubmit  = "submit"
+	createIssueOnConfirm = "confirm"
+
+	issueTrackerTimeout = 30 * time.Second
+)
+
+// The issue tracker is optional, when it's nil no issues are created
+var reportIssueTracker issueTracker
+
+var (
+	// The reports an issue is being created for right now
+	issuesInProgress      = make(map[uint64]bool)
+	issuesInProgressMutex = new(sync.Mutex)
+)
+
+var issueTrackerHTTPClient = &http.Client{Timeout: issueTrackerTimeout}
+
+// issueTracker opens issues for submitted reports in the tracker the developers use.
+// The API base URL is part of the config so the tracker can also be a self hosted one or a local stand-in.
+type issueTracker interface {
+	createIssue(issue *trackerIssue) (*trackerIssueReference, error)
+}
+
+type trackerIssue struct {
+	title       string
+	body        string
+	labels      []string
+	components  []string
+	fields      map[string]string
+	attachments []string
+}
+
+type trackerIssueReference struct {
+	ID  string
+	URL string
+}
+
+func openIssueTracker(trackerConfig issueTrackerConfig) (issueTracker, error) {
+	switch trackerConfig.Type {
+	case "":
+		return nil, nil
+	case issueTrackerGitHub:
+		return newGitHubIssueTracker(trackerConfig), nil
+	case issueTrackerGitLab:
+		return newGitLabIssueTracker(trackerConfig), nil
+	case issueTrackerJira:
+		return newJiraIssueTracker(trackerConfig)
+	}
+
+	return nil, errors.New("unknown issue tracker type \"" + trackerConfig.Type + "\"")
+}
+
+// Opens an issue for the report unless it already has one. Reports that are getting an issue right now are skipped,
+// that way pressing Confirm twice can never result in two issues for the same report.
+// The archive lock isn't held while talking to the tracker, a slow tracker shouldn't block every status update.
+func createIssueForReport(reportID uint64) {
+	if reportIssueTracker == nil || reportID == 0 {
+		return
+	}
+
+	issuesInProgressMutex.Lock()
+	if issuesInProgress[reportID] {
+		issuesInProgressMutex.Unlock()
+		return
+	}
+	issuesInProgress[reportID] = true
+	issuesInProgressMutex.Unlock()
+
+	defer func() {
+		issuesInProgressMutex.Lock()
+		delete(issuesInProgress, reportID)
+		issuesInProgressMutex.Unlock()
+	}()
+
+	archivedReportsMutex.Lock()
+	archived, loadErr := reportStorage.loadArchivedReport(reportID)
+	archivedReportsMutex.Unlock()
+	if loadErr != nil {
+		logStorageError("load archived report #"+strconv.FormatUint(reportID, 10), loadErr)
+		return
+	}
+
+	if archived.IssueURL != "" {
+		return
+	}
+
+	reference, createErr := reportIssueTracker.createIssue(generateTrackerIssue(archived))
+	if createErr != nil {
+		log.Println("Unable to create an issue for report #" + strconv.FormatUint(reportID, 10) + ": " + createErr.Error())
+		return
+	}
+
+	// The status could have changed while the issue was being created, so the report is loaded again before saving it
+	archivedReportsMutex.Lock()
+	archived, loadErr = reportStorage.loadArchivedReport(reportID)
+	if loadErr != nil {
+		archivedReportsMutex.Unlock()
+		logStorageError("load archived report #"+strconv.FormatUint(reportID, 10), loadErr)
+		return
+	}
+
+	archived.IssueID = reference.ID
+	archived.IssueURL = reference.URL
+	saveArchivedReportToStorage(archived)
+	archivedReportsMutex.Unlock()
+
+	editPostedReport(archived)
+}
+
+// Called whenever the status of a report changes, depending on the config this is the moment to open the issue
+func handleIssueTrackerStatusChange(archived *archivedReport) {
+	if config.IssueTracker.CreateOn == createIssueOnConfirm && archived.Status == reportStatusConfirmed {
+		go createIssueForReport(archived.ID)
+	}
+}
+
+func handleIssueTrackerSubmission(archived *archivedReport) {
+	if config.IssueTracker.CreateOn == "" || config.IssueTracker.CreateOn == createIssueOnSubmit {
+		go createIssueForReport(archived.ID)
+	}
+}
+
+func generateTrackerIssue(archived *archivedReport) *trackerIssue {
+	chosenType := findReportType(archived.ReportType)
+	reportTypeName := archived.ReportType
+	if chosenType != nil {
+		reportTypeName = chosenType.Name
+	}
+
+	title := strings.ReplaceAll(config.IssueTracker.TitleFormat, "{{TITLE}}", getArchivedReportTitle(archived, chosenType))
+	if title == "" {
+		title = getArchivedReportTitle(archived, chosenType)
+	}
+	title = strings.ReplaceAll(title, "{{REPORT_TYPE}}", reportTypeName)
+	title = replaceReportIDPlaceholder(title, archived.ID)
+
+	return &trackerIssue{
+		title:       strings.Join(strings.Fields(title), " "),
+		body:        generateIssueBody(archived, reportTypeName),
+		labels:      getMappedAnswers(archived, chosenType, config.IssueTracker.Labels, func(question reportQuestion) map[string]string { return question.IssueLabels }),
+		components:  getMappedAnswers(archived, chosenType, nil, func(question reportQuestion) map[string]string { return question.IssueComponents }),
+		fields:      getIssueFields(archived),
+		attachments: getArchivedAttachmentLinks(archived),
+	}
+}
+
+// The attachments of the questions are normally part of the attachments of the report as well,
+// but the archive doesn't guarantee that, so they are added when they're missing.
+// Uploads that never made it to Discord don't have a real link, those can't be passed on.
+func getArchivedAttachmentLinks(archived *archivedReport) []string {
+	links := make([]string, 0, len(archived.Attachments))
+	addLink := func(attachmentLink string) {
+		if !strings.HasPrefix(attachmentLink, discordAttachmentPrefix) && !containsString(links, attachmentLink) {
+			links = append(links, attachmentLink)
+		}
+	}
+
+	for _, attachmentLink := range archived.Attachments {
+		addLink(attachmentLink)
+	}
+	for _, answer := range archived.Answers {
+		for _, attachmentLink := range answer.Attachments {
+			addLink(attachmentLink)
+		}
+	}
+
+	return links
+}
+
+// The answer to the title question of the report type, or the name of the report type when there isn't one
+func getArchivedReportTitle(archived *archivedReport, chosenType *reportType) string {
+	if chosenType == nil {
+		return archived.ReportType
+	}
+
+	if chosenType.TitleQuestionID != "" {
+		for _, answer := range archived.Answers {
+			if answer.QuestionID == chosenType.TitleQuestionID && answer.Answer != "" {
+				return answer.Answer
+			}
+		}
+	}
+
+	return chosenType.Name
+}
+
+// Issue trackers render markdown as well, every question becomes a heading with the answer underneath
+func generateIssueBody(archived *archivedReport, reportTypeName string) string {
+	var builder strings.Builder
+	for _, answer := range archived.Answers {
+		if answer.Skipped && config.Messages.SkippedAnswer == "" {
+			continue
+		}
+
+		builder.WriteString("### ")
+		builder.WriteString(formatQuestionLabel(reportQuestion{Question: answer.Question, PrettyFormat: answer.PrettyFormat}))
+		builder.WriteString("\n")
+		if answer.Skipped {
+			builder.WriteString(config.Messages.SkippedAnswer)
+		} else {
+			builder.WriteString(answer.Answer)
+		}
+
+		for _, attachmentLink := range answer.Attachments {
+			builder.WriteString("\n")
+			builder.WriteString(attachmentLink)
+		}
+		builder.WriteString("\n\n")
+	}
+
+	if len(archived.Attachments) > 0 {
+		builder.WriteString("### ")
+		builder.WriteString(formatSectionLabel(config.Messages.Attachments))
+		for _, attachmentLink := range archived.Attachments {
+			builder.WriteString("\n- ")
+			builder.WriteString(attachmentLink)
+		}
+		builder.WriteString("\n\n")
+	}
+
+	if archived.LogSummary != "" {
+		builder.WriteString("### ")
+		builder.WriteString(formatSectionLabel(config.Messages.LogSummary))
+		builder.WriteString("\n")
+		builder.WriteString(archived.LogSummary)
+		builder.WriteString("\n\n")
+	}
+
+	for _, field := range archived.ExtraFields {
+		builder.WriteString("### ")
+		builder.WriteString(field.Name)
+		builder.WriteString("\n")
+		builder.WriteString(field.Value)
+		builder.WriteString("\n\n")
+	}
+
+	footer := strings.ReplaceAll(config.IssueTracker.BodyFooter, "{{REPORT_TYPE}}", reportTypeName)
+	footer = strings.ReplaceAll(footer, "{{REPORTER_ID}}", archived.ReporterID)
+	builder.WriteString(replaceReportIDPlaceholder(footer, archived.ID))
+
+	return strings.TrimSpace(builder.String())
+}
+
+// Fixed answers can be mapped to labels and components, the given defaults are always added
+func getMappedAnswers(archived *archivedReport, chosenType *reportType, defaults []string, getMapping func(question reportQuestion) map[string]string) []string {
+	mapped := make([]string, 0, len(defaults))
+	for _, value := range defaults {
+		if !containsString(mapped, value) {
+			mapped = append(mapped, value)
+		}
+	}
+
+	if chosenType == nil {
+		return mapped
+	}
+
+	for _, answer := range archived.Answers {
+		if answer.QuestionID == "" || answer.Skipped {
+			continue
+		}
+
+		for _, question := range chosenType.Questions {
+			if question.ID != answer.QuestionID {
+				continue
+			}
+
+			for fixedAnswer, value := range getMapping(question) {
+				if strings.EqualFold(fixedAnswer, answer.Answer) && !containsString(mapped, value) {
+					mapped = append(mapped, value)
+				}
+			}
+		}
+	}
+
+	return mapped
+}
+
+// Answers can be put in fields of the issue as well, the field mapping goes from question ID to field name
+func getIssueFields(archived *archivedReport) map[string]string {
+	fields := make(map[string]string)
+	for _, answer := range archived.Answers {
+		if fieldName, ok := config.IssueTracker.FieldMapping[answer.QuestionID]; ok && answer.QuestionID != "" && !answer.Skipped {
+			fields[fieldName] = answer.Answer
+		}
+	}
+
+	return fields
+}
+
+// Sends a JSON request to the API of the tracker and decodes the JSON response into the given value
+func sendTrackerRequest(method, requestURL string, headers map[string]string, payload, response interface{}) error {
+	body, marshalErr := json.Marshal(payload)
+	if marshalErr != nil {
+		return marshalErr
+	}
+
+	request, requestErr := http.NewRequest(method, requestURL, bytes.NewReader(body))
+	if requestErr != nil {
+		return requestErr
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+
+	httpResponse, sendErr := issueTrackerHTTPClient.Do(request)
+	if sendErr != nil {
+		return sendErr
+	}
+	defer httpResponse.Body.Close()
+
+	responseBody, readErr := ioutil.ReadAll(io.LimitReader(httpResponse.Body, 1024*1024))
+	if readErr != nil {
+		return readErr
+	}
+
+	if httpResponse.StatusCode < 200 || httpResponse.StatusCode > 299 {
+		return errors.New("unexpected status " + httpResponse.Status + ": " + truncateText(string(responseBody), 200))
+	}
+
+	if response == nil {
+		return nil
+	}
+
+	return json.Unmarshal(responseBody, response)
+}
+
+type issueTrackerConfig struct {
+	Type         string            `json:"type"`
+	APIBaseURL   string            `json:"api_base_url"`
+	Token        string            `json:"token"`
+	Repository   string            `json:"repository"`
+	CreateOn     string            `json:"create_on"`
+	TitleFormat  string            `json:"title_format"`
+	BodyFooter   string            `json:"body_footer"`
+	Labels       []string          `json:"labels"`
+	FieldMapping map[string]string `json:"field_mapping,omitempty"`
+	Jira         jiraConfig        `json:"jira"`
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultJiraIssueType = "Bug"
+
+	// Select lists and radio buttons expect {"value": ...}, fields like versions and users expect {"name": ...}
+	jiraFieldTypeText   = "text"
+	jiraFieldTypeValue  = "value"
+	jiraFieldTypeName   = "name"
+	jiraFieldTypeNumber = "number"
+)
+
+type jiraIssueTracker struct {
+	apiBaseURL    string
+	authorization string
+	projectKey    string
+	issueType     string
+	fieldTypes    map[string]string
+}
+
+func newJiraIssueTracker(trackerConfig issueTrackerConfig) (*jiraIssueTracker, error) {
+	if trackerConfig.APIBaseURL == "" || trackerConfig.Jira.ProjectKey == "" {
+		return nil, errors.New("the jira issue tracker needs an api base url and a project key")
+	}
+
+	issueType := trackerConfig.Jira.IssueType
+	if issueType == "" {
+		issueType = defaultJiraIssueType
+	}
+
+	for fieldName, fieldType := range trackerConfig.Jira.FieldTypes {
+		switch fieldType {
+		case jiraFieldTypeText, jiraFieldTypeValue, jiraFieldTypeName, jiraFieldTypeNumber:
+		default:
+			return nil, errors.New("unknown type \"" + fieldType + "\" for jira field " + fieldName)
+		}
+	}
+
+	// Jira Cloud uses an email with an API token, Jira Server and Data Center use personal access tokens
+	authorization := "Bearer " + trackerConfig.Token
+	if trackerConfig.Jira.Email != "" {
+		authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(trackerConfig.Jira.Email+":"+trackerConfig.Token))
+	}
+
+	return &jiraIssueTracker{
+		apiBaseURL:    strings.TrimSuffix(trackerConfig.APIBaseURL, "/"),
+		authorization: authorization,
+		projectKey:    trackerConfig.Jira.ProjectKey,
+		issueType:     issueType,
+		fieldTypes:    trackerConfig.Jira.FieldTypes,
+	}, nil
+}
+
+func (tracker *jiraIssueTracker) createIssue(issue *trackerIssue) (*trackerIssueReference, error) {
+	fields := map[string]interface{}{
+		"project":     map[string]string{"key": tracker.projectKey},
+		"issuetype":   map[string]string{"name": tracker.issueType},
+		"summary":     truncateText(issue.title, 255),
+		"description": convertMarkdownToJira(issue.body),
+	}
+
+	// Labels in Jira can't contain spaces
+	if len(issue.labels) > 0 {
+		labels := make([]string, len(issue.labels))
+		for index, label := range issue.labels {
+			labels[index] = strings.Join(strings.Fields(label), "-")
+		}
+		fields["labels"] = labels
+	}
+
+	if len(issue.components) > 0 {
+		components := make([]map[string]string, len(issue.components))
+		for index, component := range issue.components {
+			components[index] = map[string]string{"name": component}
+		}
+		fields["components"] = components
+	}
+
+	for fieldName, value := range issue.fields {
+		fieldValue, fieldErr := formatJiraFieldValue(tracker.fieldTypes[fieldName], value)
+		if fieldErr != nil {
+			log.Println("Leaving out jira field " + fieldName + ": " + fieldErr.Error())
+			continue
+		}
+		fields[fieldName] = fieldValue
+	}
+
+	var response struct {
+		Key string `json:"key"`
+	}
+
+	requestErr := sendTrackerRequest(http.MethodPost, tracker.apiBaseURL+"/rest/api/2/issue", map[string]string{
+		"Authorization": tracker.authorization,
+	}, map[string]interface{}{"fields": fields}, &response)
+	if requestErr != nil {
+		return nil, requestErr
+	}
+
+	// The issue exists at this point, attachments that fail to upload only end up in the log
+	for index, attachmentLink := range issue.attachments {
+		if uploadErr := tracker.uploadAttachment(response.Key, attachmentLink, index); uploadErr != nil {
+			log.Println("Unable to upload an attachment to Jira issue " + response.Key + ": " + uploadErr.Error())
+		}
+	}
+
+	return &trackerIssueReference{
+		ID:  response.Key,
+		URL: getJiraIssueURL(tracker.apiBaseURL, response.Key),
+	}, nil
+}
+
+// Fields without a type are sent as text, that's what most custom fields expect
+func formatJiraFieldValue(fieldType, value string) (interface{}, error) {
+	switch fieldType {
+	case jiraFieldTypeValue:
+		return map[string]string{"value": value}, nil
+	case jiraFieldTypeName:
+		return map[string]string{"name": value}, nil
+	case jiraFieldTypeNumber:
+		return strconv.ParseFloat(strings.TrimSpace(value), 64)
+	}
+
+	return value, nil
+}
+
+// The webhooks of Jira only contain the key of the issue, so this is also used to find the report again
+func getJiraIssueURL(apiBaseURL, issueKey string) string {
+	return strings.TrimSuffix(apiBaseURL, "/") + "/browse/" + issueKey
+}
+
+func (tracker *jiraIssueTracker) uploadAttachment(issueKey, attachmentLink string, index int) error {
+	attachment, downloadErr := downloadAttachment(attachmentLink, index)
+	if downloadErr != nil {
+		return downloadErr
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, partErr := writer.CreateFormFile("file", attachment.name)
+	if partErr != nil {
+		return partErr
+	}
+	if _, writeErr := part.Write(attachment.data); writeErr != nil {
+		return writeErr
+	}
+	if closeErr := writer.Close(); closeErr != nil {
+		return closeErr
+	}
+
+	request, requestErr := http.NewRequest(http.MethodPost, tracker.apiBaseURL+"/rest/api/2/issue/"+issueKey+"/attachments", &body)
+	if requestErr != nil {
+		return requestErr
+	}
+
+	request.Header.Set("Authorization", tracker.authorization)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	request.Header.Set("X-Atlassian-Token", "no-check")
+
+	response, sendErr := issueTrackerHTTPClient.Do(request)
+	if sendErr != nil {
+		return sendErr
+	}
+	defer response.Body.Close()
+	io.Copy(ioutil.Discard, response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return errors.New("unexpected status " + response.Status)
+	}
+
+	return nil
+}
+
+// The body of an issue is written in markdown, Jira has its own wiki markup for the few things we use
+func convertMarkdownToJira(markdown string) string {
+	lines := strings.Split(markdown, "\n")
+	for index, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "### "):
+			line = "h3. " + strings.TrimPrefix(line, "### ")
+		case strings.HasPrefix(line, "- "):
+			line = "* " + strings.TrimPrefix(line, "- ")
+		case line == "```":
+			line = "{code}"
+		}
+
+		line = strings.ReplaceAll(line, "**", "*")
+		lines[index] = strings.ReplaceAll(line, "__", "+")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+type jiraConfig struct {
+	ProjectKey string            `json:"project_key,omitempty"`
+	IssueType  string            `json:"issue_type,omitempty"`
+	Email      string            `json:"email,omitempty"`
+	FieldTypes map[string]string `json:"field_types,omitempty"`
+}
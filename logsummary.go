@@ -0,0 +1,187 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Only the start of a log is read, that's where the version and first exception usually are
+	maxLogFileReadSize = 5 * 1024 * 1024
+	maxLogFindingSize  = 500
+)
+
+var defaultLogFileExtensions = []string{".log", ".txt"}
+
+// Compiles the patterns of the log extractors, the first capturing group is used as the finding when there is one.
+// Patterns can use (?s) to match across lines, for example to capture an exception together with its stack trace.
+func prepareLogExtractors() error {
+	for index := range config.LogExtractors {
+		extractor := &config.LogExtractors[index]
+		if extractor.Name == "" {
+			return errors.New("every log extractor needs a name")
+		}
+
+		compiledPattern, compileErr := regexp.Compile(extractor.Pattern)
+		if compileErr != nil {
+			return compileErr
+		}
+		extractor.compiledPattern = compiledPattern
+	}
+
+	return nil
+}
+
+func isLogFile(attachment *discordgo.MessageAttachment) bool {
+	extensions := config.LogFileExtensions
+	if len(extensions) == 0 {
+		extensions = defaultLogFileExtensions
+	}
+
+	extension := strings.ToLower(path.Ext(attachment.Filename))
+	for _, logExtension := range extensions {
+		if extension != "" && strings.ToLower("."+strings.TrimPrefix(logExtension, ".")) == extension {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Only the attachments that are accepted are read, there's no point in downloading a file that gets rejected anyway
+func extractMessageLogFindings(message *discordgo.MessageCreate, accepted []bool) [][]logFinding {
+	logFindings := make([][]logFinding, len(message.Attachments))
+	for index, attachment := range message.Attachments {
+		if accepted[index] {
+			logFindings[index] = extractLogFindings(attachment)
+		}
+	}
+
+	return logFindings
+}
+
+func hasLogFiles(message *discordgo.MessageCreate) bool {
+	if len(config.LogExtractors) == 0 {
+		return false
+	}
+
+	for _, attachment := range message.Attachments {
+		if isLogFile(attachment) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Downloads the log file and runs every extractor over it, extractors without a match are left out
+func extractLogFindings(attachment *discordgo.MessageAttachment) []logFinding {
+	if len(config.LogExtractors) == 0 || !isLogFile(attachment) {
+		return nil
+	}
+
+	content, fetchErr := fetchLogFile(attachment.URL)
+	if fetchErr != nil {
+		log.Println("Unable to read the log file " + attachment.Filename + ": " + fetchErr.Error())
+		return nil
+	}
+
+	findings := make([]logFinding, 0)
+	for _, extractor := range config.LogExtractors {
+		match := extractor.compiledPattern.FindStringSubmatch(content)
+		if match == nil {
+			continue
+		}
+
+		value := match[0]
+		if len(match) > 1 {
+			value = match[1]
+		}
+
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		findings = append(findings, logFinding{
+			Name:  extractor.Name,
+			Value: truncateText(strings.ReplaceAll(value, "`", "'"), maxLogFindingSize),
+		})
+	}
+
+	return findings
+}
+
+func fetchLogFile(attachmentLink string) (string, error) {
+	response, getErr := attachmentHTTPClient.Get(attachmentLink)
+	if getErr != nil {
+		return "", getErr
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", errors.New("unexpected status " + response.Status)
+	}
+
+	data, readErr := ioutil.ReadAll(io.LimitReader(response.Body, maxLogFileReadSize))
+	if readErr != nil {
+		return "", readErr
+	}
+
+	return strings.ToValidUTF8(string(data), ""), nil
+}
+
+// Every log file gets its own part in the summary. The findings come straight from a file of the user,
+// so they are always put in code, that way they can't mention anyone or mess up the formatting.
+func generateLogSummary(report *reportData) string {
+	var builder strings.Builder
+	for _, attachment := range report.attachments {
+		if len(attachment.logFindings) == 0 {
+			continue
+		}
+
+		if builder.Len() > 0 {
+			builder.WriteString("\n")
+		}
+		builder.WriteString("__")
+		builder.WriteString(strings.ReplaceAll(attachment.fileName, "@", "at"))
+		builder.WriteString("__")
+
+		for _, finding := range attachment.logFindings {
+			builder.WriteString("\n**")
+			builder.WriteString(finding.Name)
+			builder.WriteString(":**")
+			if strings.Contains(finding.Value, "\n") {
+				builder.WriteString("\n```\n")
+				builder.WriteString(finding.Value)
+				builder.WriteString("\n```")
+			} else {
+				builder.WriteString(" `")
+				builder.WriteString(finding.Value)
+				builder.WriteString("`")
+			}
+		}
+	}
+
+	return builder.String()
+}
+
+type logExtractor struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+
+	compiledPattern *regexp.Regexp
+}
+
+type logFinding struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
@@ -23,6 +23,8 @@ func handleIncomingMessage(session *discordgo.Session, message *discordgo.Messag
 	}
 
 	if channel.Type != discordgo.ChannelTypeDM {
+		handleStaffStatusCommand(message)
+		handleStaffRelayMessage(message)
 		return
 	}
 
@@ -30,15 +32,34 @@ func handleIncomingMessage(session *discordgo.Session, message *discordgo.Messag
 	currentReportsMutex.RLock()
 	if report, ok := currentOngoingReports[message.Author.ID]; ok {
 		currentReportsMutex.RUnlock()
+
+		// Log files are downloaded while the report isn't locked, a large log shouldn't keep the report locked.
+		// The report is only locked for a moment to find out which attachments it's going to accept.
+		var logFindings [][]logFinding
+		if hasLogFiles(message) {
+			report.lock.Lock()
+			accepted := findAcceptedAttachments(report, message.Attachments)
+			report.lock.Unlock()
+
+			logFindings = extractMessageLogFindings(message, accepted)
+		}
+
 		report.lock.Lock()
 		defer report.lock.Unlock()
 
 		// The user is already in an ongoing conversation, continue it
-		continueOngoingReport(report, message.Content, message.Author.ID, message)
+		continueOngoingReport(report, message.Content, message.Author.ID, message, logFindings)
+		persistOngoingReport(message.Author.ID, report)
 	} else {
-		// The user is not in an ongoing conversation, make sure to start a new one
 		currentReportsMutex.RUnlock()
-		startNewReportConversation(message.Author.ID, "")
+
+		// Replies to the staff about a submitted report aren't the start of a new report
+		if handleReporterRelayMessage(message) {
+			return
+		}
+
+		// The user is not in an ongoing conversation, make sure to start a new one
+		startNewReportConversation(message.Author.ID, "", "")
 	}
 }
 
@@ -47,18 +68,42 @@ func sendInteractionComponentIfNeeded(message *discordgo.MessageCreate) {
 		return
 	}
 
-	botSession.ChannelMessageSendComplex(message.ChannelID, &discordgo.MessageSend{
-		Content: config.Messages.InteractionButtonContent,
-		Components: []discordgo.MessageComponent{
-			discordgo.ActionsRow{
-				Components: []discordgo.MessageComponent{
-					discordgo.Button{
-						CustomID: bugReportButtonID,
-						Label:    "Start A Report",
-						Style:    discordgo.PrimaryButton,
-					},
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					CustomID: bugReportButtonID,
+					Label:    "Start A Report",
+					Style:    discordgo.PrimaryButton,
 				},
 			},
 		},
+	}
+
+	// With multiple report types people can pick one right away instead of being asked in their DMs
+	if len(config.ReportTypes) > 1 {
+		options := make([]discordgo.SelectMenuOption, len(config.ReportTypes))
+		for index, value := range config.ReportTypes {
+			options[index] = discordgo.SelectMenuOption{
+				Label:       value.Name,
+				Value:       value.ID,
+				Description: value.Description,
+			}
+		}
+
+		components = append(components, discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					CustomID:    reportTypeSelectID,
+					Placeholder: config.Messages.ReportTypeSelectPlaceholder,
+					Options:     options,
+				},
+			},
+		})
+	}
+
+	botSession.ChannelMessageSendComplex(message.ChannelID, &discordgo.MessageSend{
+		Content:    config.Messages.InteractionButtonContent,
+		Components: components,
 	})
 }
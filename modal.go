@@ -0,0 +1,284 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	reportModalPrefix     = "report_modal:"
+	modalInputPrefix      = "question_"
+	maxModalInputs        = 5
+	maxModalTitleLength   = 45
+	maxModalLabelLength   = 45
+	maxModalPlaceholder   = 100
+	maxModalInputLength   = 4000
+	modalFixedAnswerJoint = " / "
+)
+
+// Short report types can be filled in with a single modal. Modals can't change while they're open,
+// so report types with conditional questions always use the Direct Message conversation instead.
+func canUseModal(chosenType *reportType) bool {
+	// Modals can't contain files either, so a report type that requires a screenshot can't use them
+	if !config.UseModalsForShortReports || len(chosenType.Questions) > maxModalInputs || chosenType.RequireScreenshot {
+		return false
+	}
+
+	for _, question := range chosenType.Questions {
+		if len(question.Conditions) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Opens the modal for the report type if possible, when this returns false the regular conversation should be started
+func tryOpenReportModal(session *discordgo.Session, interaction *discordgo.InteractionCreate, userID, reportTypeID string) (opened bool) {
+	chosenType := findReportType(reportTypeID)
+	if chosenType == nil && len(config.ReportTypes) == 1 {
+		chosenType = config.ReportTypes[0]
+	}
+
+	if chosenType == nil || !canUseModal(chosenType) {
+		return false
+	}
+
+	// The conversation already takes care of telling the user why they can't start a report
+	currentReportsMutex.RLock()
+	alreadyInProcess := isAlreadyInReportProcess(userID)
+	currentReportsMutex.RUnlock()
+	if alreadyInProcess || isUserOnReportCooldown(userID, chosenType.ID) {
+		return false
+	}
+
+	rows := make([]discordgo.MessageComponent, len(chosenType.Questions))
+	for index, question := range chosenType.Questions {
+		placeholder := question.Question
+		if len(question.FixedAnswers) > 0 {
+			placeholder = strings.Join(question.FixedAnswers, modalFixedAnswerJoint)
+		}
+
+		rows[index] = discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.TextInput{
+					CustomID:    modalInputPrefix + strconv.Itoa(index),
+					Label:       truncateText(formatQuestionLabel(question), maxModalLabelLength),
+					Style:       discordgo.TextInputParagraph,
+					Placeholder: truncateText(placeholder, maxModalPlaceholder),
+					Required:    !question.Optional,
+					MaxLength:   maxModalInputLength,
+				},
+			},
+		}
+	}
+
+	respondErr := session.InteractionRespond(interaction.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID:   reportModalPrefix + chosenType.ID,
+			Title:      truncateText(chosenType.Name, maxModalTitleLength),
+			Components: rows,
+		},
+	})
+
+	return respondErr == nil
+}
+
+func handleReportModalSubmit(session *discordgo.Session, interaction *discordgo.InteractionCreate) {
+	data := interaction.ModalSubmitData()
+	if !strings.HasPrefix(data.CustomID, reportModalPrefix) {
+		return
+	}
+
+	user := getInteractionUser(interaction)
+	chosenType := findReportType(strings.TrimPrefix(data.CustomID, reportModalPrefix))
+	if user == nil || chosenType == nil {
+		return
+	}
+
+	// Posting the report can take a while, so we let Discord know we're working on it
+	session.InteractionRespond(interaction.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+
+	response := handleModalAnswers(chosenType, user.ID, readModalValues(data))
+	session.InteractionResponseEdit(interaction.Interaction, &discordgo.WebhookEdit{
+		Content: &response,
+	})
+}
+
+// Validates the answers of the modal just like the conversation would. If everything is fine the report is submitted
+// right away, otherwise the report continues in the Direct Messages so the user doesn't lose what they've typed.
+func handleModalAnswers(chosenType *reportType, userID string, values map[string]string) (response string) {
+	currentReportsMutex.RLock()
+	alreadyInProcess := isAlreadyInReportProcess(userID)
+	currentReportsMutex.RUnlock()
+	if alreadyInProcess {
+		return formatAlreadyCreatingReport()
+	}
+
+	if isUserOnReportCooldown(userID, chosenType.ID) {
+		return config.Messages.ReportCooldown
+	}
+
+	report := &reportData{
+		attachments:     make([]reportAttachment, 0),
+		lastInteraction: time.Now(),
+		lock:            new(sync.Mutex),
+	}
+	startReportQuestions(report, chosenType)
+
+	firstInvalidIndex := -1
+	firstErrorMessage := ""
+	for index := range report.data {
+		content := strings.TrimSpace(values[modalInputPrefix+strconv.Itoa(index)])
+		if content == "" && report.data[index].question.Optional {
+			report.data[index].skipped = true
+			continue
+		}
+
+		// The validation functions always look at the current question
+		report.currentQuestionIndex = uint(index)
+
+		errorMessage := ""
+		valid := content != ""
+		if valid && !isValidFixedQuestionAnswer(report, content) {
+			errorMessage = config.Messages.InvalidFixedQuestionAnswer
+			for _, value := range report.data[index].question.FixedAnswers {
+				errorMessage += "\n- " + value
+			}
+			valid = false
+		} else if valid {
+			errorMessage, valid = validateAnswer(report, content)
+		}
+
+		if !valid {
+			if firstInvalidIndex == -1 {
+				firstInvalidIndex = index
+				firstErrorMessage = errorMessage
+			}
+			continue
+		}
+
+		report.data[index].answer = strings.ReplaceAll(content, "@", "at")
+	}
+
+	// The report only counts as started once it's either submitted or stored as an ongoing report
+	started := false
+	if firstInvalidIndex == -1 && !isReportTooLarge(report, userID) {
+		fireOngoingReportEvent(reportEventStarted, userID, report)
+		started = true
+
+		submittedMessage, submitted := submitReport(report, userID)
+		if submitted {
+			return submittedMessage
+		}
+
+		// The submission hook vetoed the report or it couldn't be posted, the user can still try again in the Direct Messages
+		sendMessageToDM(submittedMessage, userID)
+	}
+
+	// From here on only the invalid answers still have to be given, after that the user ends up in the submit menu
+	report.hasReachedEnd = true
+
+	// Just like a new conversation the report is claimed first, the Direct Messages are sent once the global lock is released
+	currentReportsMutex.Lock()
+	alreadyInProcess = isAlreadyInReportProcess(userID)
+	if !alreadyInProcess {
+		report.lock.Lock()
+		currentOngoingReports[userID] = report
+	}
+	currentReportsMutex.Unlock()
+
+	if alreadyInProcess {
+		if started {
+			fireOngoingReportEvent(reportEventCancelled, userID, report)
+		}
+		return formatAlreadyCreatingReport()
+	}
+	defer report.lock.Unlock()
+
+	var succeeded bool
+	if firstInvalidIndex != -1 {
+		report.currentQuestionIndex = uint(firstInvalidIndex)
+		report.shouldReadAnswer = true
+		if firstErrorMessage != "" {
+			sendMessageToDM(firstErrorMessage, userID)
+		}
+		succeeded = sendReportQuestion(report, userID, false)
+	} else {
+		report.currentQuestionIndex = uint(len(report.data)) - 1
+		succeeded = sendMessageToDM(config.Messages.ModalContinueInDM, userID)
+		if succeeded {
+			handleSubmittingProcess(report, userID)
+		}
+	}
+
+	if !succeeded {
+		removeReportAndUserFromCache(userID, report)
+		if started {
+			fireOngoingReportEvent(reportEventCancelled, userID, report)
+		}
+		return strings.ReplaceAll(config.Messages.UnableToDMPerson, "{{USER_TAG}}", "<@"+userID+">")
+	}
+
+	persistOngoingReport(userID, report)
+	if !started {
+		fireOngoingReportEvent(reportEventStarted, userID, report)
+	}
+
+	return config.Messages.ModalMovedToDM
+}
+
+func readModalValues(data discordgo.ModalSubmitInteractionData) map[string]string {
+	values := make(map[string]string)
+	for _, row := range data.Components {
+		actionsRow, ok := row.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+
+		for _, component := range actionsRow.Components {
+			if input, ok := component.(*discordgo.TextInput); ok {
+				values[input.CustomID] = input.Value
+			}
+		}
+	}
+
+	return values
+}
+
+// The pretty format usually contains markdown, which isn't rendered in labels, field names and menus
+func formatQuestionLabel(question reportQuestion) string {
+	label := strings.TrimSpace(strings.TrimSuffix(strings.Trim(question.PrettyFormat, "*_ "), ":"))
+	if label == "" {
+		return question.Question
+	}
+
+	return label
+}
+
+func truncateText(text string, maxLength int) string {
+	if utf8.RuneCountInString(text) <= maxLength {
+		return text
+	}
+
+	// There's no room for the ellipsis when the limit is this small
+	if maxLength <= 3 {
+		if maxLength < 0 {
+			maxLength = 0
+		}
+		return string([]rune(text)[:maxLength])
+	}
+
+	return string([]rune(text)[:maxLength-3]) + "..."
+}
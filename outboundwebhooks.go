@@ -0,0 +1,397 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	reportEventStarted       = "report.started"
+	reportEventSubmitted     = "report.submitted"
+	reportEventStatusChanged = "report.status_changed"
+	reportEventCancelled     = "report.cancelled"
+	reportEventTimedOut      = "report.timed_out"
+
+	// Bump this whenever the payload changes in a way receivers could trip over
+	webhookPayloadVersion = 1
+
+	defaultWebhookQueuePath   = "./data/webhook_queue.json"
+	defaultWebhookMaxAttempts = 10
+	webhookRetryBaseDelay     = 30 * time.Second
+	webhookRetryMaxDelay      = time.Hour
+	webhookDeliveryInterval   = 5 * time.Second
+	webhookRequestTimeout     = 15 * time.Second
+)
+
+// The queue is nil when there are no outbound webhooks configured
+var outboundWebhookQueue *webhookQueue
+
+var webhookHTTPClient = &http.Client{Timeout: webhookRequestTimeout}
+
+// webhookQueue keeps every delivery on disk until the receiver accepted it, that way events survive
+// both a receiver that is down and a restart of the bot.
+type webhookQueue struct {
+	path       string
+	lock       *sync.Mutex
+	deliveries []*webhookDelivery
+	wake       chan struct{}
+}
+
+type webhookDelivery struct {
+	ID          string          `json:"id"`
+	WebhookID   string          `json:"webhook_id"`
+	URL         string          `json:"url"`
+	Event       string          `json:"event"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"attempts"`
+	NextAttempt time.Time       `json:"next_attempt"`
+}
+
+type webhookPayload struct {
+	Version    int            `json:"version"`
+	Event      string         `json:"event"`
+	Timestamp  time.Time      `json:"timestamp"`
+	ReporterID string         `json:"reporter_id"`
+	ReportType string         `json:"report_type,omitempty"`
+	Report     *webhookReport `json:"report,omitempty"`
+}
+
+// Only submitted reports have this part, it's kept apart from the archive so the storage format can change freely
+type webhookReport struct {
+	ID              uint64           `json:"id"`
+	Status          reportStatus     `json:"status"`
+	StatusUpdatedBy string           `json:"status_updated_by,omitempty"`
+	StaffNote       string           `json:"staff_note,omitempty"`
+	SubmittedAt     time.Time        `json:"submitted_at"`
+	Answers         []archivedAnswer `json:"answers"`
+	Attachments     []string         `json:"attachments"`
+	LogSummary      string           `json:"log_summary,omitempty"`
+	ExtraFields     []reportField    `json:"extra_fields,omitempty"`
+	ChannelID       string           `json:"channel_id,omitempty"`
+	MessageID       string           `json:"message_id,omitempty"`
+	ThreadID        string           `json:"thread_id,omitempty"`
+	IssueID         string           `json:"issue_id,omitempty"`
+	IssueURL        string           `json:"issue_url,omitempty"`
+}
+
+func openWebhookQueue(webhooksConfig outboundWebhooksConfig) (*webhookQueue, error) {
+	if len(webhooksConfig.Webhooks) == 0 {
+		return nil, nil
+	}
+
+	// The ID ties the queued deliveries to their webhook, so changing the URL doesn't lose the deliveries
+	webhookIDs := make([]string, 0, len(webhooksConfig.Webhooks))
+	for _, webhook := range webhooksConfig.Webhooks {
+		if webhook.ID == "" || webhook.URL == "" || webhook.Secret == "" {
+			return nil, errors.New("every outbound webhook needs an ID, a URL and a secret")
+		}
+
+		if containsString(webhookIDs, webhook.ID) {
+			return nil, errors.New("the outbound webhook ID \"" + webhook.ID + "\" is used more than once")
+		}
+		webhookIDs = append(webhookIDs, webhook.ID)
+	}
+
+	path := webhooksConfig.QueuePath
+	if path == "" {
+		path = defaultWebhookQueuePath
+	}
+	path = filepath.FromSlash(path)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	queue := &webhookQueue{
+		path:       path,
+		lock:       new(sync.Mutex),
+		deliveries: make([]*webhookDelivery, 0),
+		wake:       make(chan struct{}, 1),
+	}
+
+	fileBytes, fileErr := ioutil.ReadFile(path)
+	if os.IsNotExist(fileErr) {
+		return queue, nil
+	}
+	if fileErr != nil {
+		return nil, fileErr
+	}
+
+	if len(fileBytes) > 0 {
+		if jsonErr := json.Unmarshal(fileBytes, &queue.deliveries); jsonErr != nil {
+			return nil, jsonErr
+		}
+	}
+
+	return queue, nil
+}
+
+func fireOngoingReportEvent(event, userID string, report *reportData) {
+	payload := &webhookPayload{
+		Event:      event,
+		ReporterID: userID,
+	}
+
+	// The report type isn't known yet while the user is still choosing one
+	if report.reportType != nil {
+		payload.ReportType = report.reportType.ID
+	}
+
+	queueWebhookEvent(payload)
+}
+
+func fireArchivedReportEvent(event string, archived *archivedReport) {
+	queueWebhookEvent(&webhookPayload{
+		Event:      event,
+		ReporterID: archived.ReporterID,
+		ReportType: archived.ReportType,
+		Report: &webhookReport{
+			ID:              archived.ID,
+			Status:          archived.Status,
+			StatusUpdatedBy: archived.StatusUpdatedBy,
+			StaffNote:       archived.StaffNote,
+			SubmittedAt:     archived.SubmittedAt,
+			Answers:         archived.Answers,
+			Attachments:     archived.Attachments,
+			LogSummary:      archived.LogSummary,
+			ExtraFields:     archived.ExtraFields,
+			ChannelID:       archived.ChannelID,
+			MessageID:       archived.MessageID,
+			ThreadID:        archived.ThreadID,
+			IssueID:         archived.IssueID,
+			IssueURL:        archived.IssueURL,
+		},
+	})
+}
+
+// Adds a delivery for every webhook that wants the event, the actual sending happens in the background
+func queueWebhookEvent(payload *webhookPayload) {
+	if outboundWebhookQueue == nil {
+		return
+	}
+
+	payload.Version = webhookPayloadVersion
+	payload.Timestamp = time.Now()
+
+	payloadBytes, jsonErr := json.Marshal(payload)
+	if jsonErr != nil {
+		log.Println("Unable to generate the webhook payload of " + payload.Event + ": " + jsonErr.Error())
+		return
+	}
+
+	queue := outboundWebhookQueue
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+
+	queued := false
+	for _, webhook := range config.OutboundWebhooks.Webhooks {
+		if len(webhook.Events) > 0 && !containsString(webhook.Events, payload.Event) {
+			continue
+		}
+
+		queue.deliveries = append(queue.deliveries, &webhookDelivery{
+			ID:          generateDeliveryID(),
+			WebhookID:   webhook.ID,
+			URL:         webhook.URL,
+			Event:       payload.Event,
+			Payload:     payloadBytes,
+			NextAttempt: time.Now(),
+		})
+		queued = true
+	}
+
+	if !queued {
+		return
+	}
+
+	if flushErr := queue.flush(); flushErr != nil {
+		log.Println("Unable to save the webhook queue: " + flushErr.Error())
+	}
+
+	// When there's already a wake up pending the worker will pick this delivery up as well
+	select {
+	case queue.wake <- struct{}{}:
+	default:
+	}
+}
+
+func startWebhookDelivery() {
+	ticker := time.NewTicker(webhookDeliveryInterval)
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-outboundWebhookQueue.wake:
+		}
+
+		outboundWebhookQueue.deliverDueWebhooks()
+	}
+}
+
+// The deliveries are sent without holding the lock, otherwise a slow receiver would block every report event
+func (queue *webhookQueue) deliverDueWebhooks() {
+	currentTime := time.Now()
+
+	queue.lock.Lock()
+	due := make([]*webhookDelivery, 0)
+	for _, delivery := range queue.deliveries {
+		if !currentTime.Before(delivery.NextAttempt) {
+			due = append(due, delivery)
+		}
+	}
+	queue.lock.Unlock()
+
+	if len(due) == 0 {
+		return
+	}
+
+	finished := make(map[*webhookDelivery]bool)
+	failed := make(map[*webhookDelivery]error)
+	for _, delivery := range due {
+		webhook := findOutboundWebhook(delivery.WebhookID)
+		if webhook == nil {
+			// The webhook has been removed from the config since the event happened
+			finished[delivery] = true
+			continue
+		}
+
+		if sendErr := sendWebhookDelivery(webhook, delivery); sendErr != nil {
+			failed[delivery] = sendErr
+			continue
+		}
+
+		finished[delivery] = true
+	}
+
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+
+	remaining := make([]*webhookDelivery, 0, len(queue.deliveries))
+	for _, delivery := range queue.deliveries {
+		if finished[delivery] {
+			continue
+		}
+
+		if sendErr, ok := failed[delivery]; ok {
+			delivery.Attempts++
+
+			maxAttempts := config.OutboundWebhooks.MaxAttempts
+			if maxAttempts <= 0 {
+				maxAttempts = defaultWebhookMaxAttempts
+			}
+
+			if delivery.Attempts >= maxAttempts {
+				log.Println("Giving up on webhook delivery " + delivery.ID + " (" + delivery.Event + ") to " + delivery.URL + ": " + sendErr.Error())
+				continue
+			}
+
+			delivery.NextAttempt = currentTime.Add(getWebhookRetryDelay(delivery.Attempts))
+		}
+
+		remaining = append(remaining, delivery)
+	}
+
+	queue.deliveries = remaining
+	if flushErr := queue.flush(); flushErr != nil {
+		log.Println("Unable to save the webhook queue: " + flushErr.Error())
+	}
+}
+
+// The payload is signed with the secret of the webhook so the receiver knows it really comes from the bot
+func sendWebhookDelivery(webhook *outboundWebhook, delivery *webhookDelivery) error {
+	request, requestErr := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if requestErr != nil {
+		return requestErr
+	}
+
+	mac := hmac.New(sha256.New, []byte(webhook.Secret))
+	mac.Write(delivery.Payload)
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Webhook-Event", delivery.Event)
+	request.Header.Set("X-Webhook-Delivery", delivery.ID)
+	request.Header.Set("X-Webhook-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+	response, responseErr := webhookHTTPClient.Do(request)
+	if responseErr != nil {
+		return responseErr
+	}
+	defer response.Body.Close()
+	io.Copy(ioutil.Discard, response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return errors.New("the receiver responded with status " + strconv.Itoa(response.StatusCode))
+	}
+
+	return nil
+}
+
+// Every failed attempt doubles the time until the next one, up to the maximum delay
+func getWebhookRetryDelay(attempts int) time.Duration {
+	delay := webhookRetryBaseDelay
+	for index := 1; index < attempts && delay < webhookRetryMaxDelay; index++ {
+		delay *= 2
+	}
+
+	if delay > webhookRetryMaxDelay {
+		return webhookRetryMaxDelay
+	}
+
+	return delay
+}
+
+func findOutboundWebhook(webhookID string) *outboundWebhook {
+	for index, webhook := range config.OutboundWebhooks.Webhooks {
+		if webhook.ID == webhookID {
+			return &config.OutboundWebhooks.Webhooks[index]
+		}
+	}
+
+	return nil
+}
+
+// Receivers can use the delivery ID to ignore deliveries they've already handled
+func generateDeliveryID() string {
+	idBytes := make([]byte, 16)
+	if _, randErr := rand.Read(idBytes); randErr != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+
+	return hex.EncodeToString(idBytes)
+}
+
+func (queue *webhookQueue) flush() error {
+	fileBytes, jsonErr := json.Marshal(queue.deliveries)
+	if jsonErr != nil {
+		return jsonErr
+	}
+
+	return writeFileAtomically(queue.path, fileBytes)
+}
+
+type outboundWebhooksConfig struct {
+	QueuePath   string            `json:"queue_path"`
+	MaxAttempts int               `json:"max_attempts"`
+	Webhooks    []outboundWebhook `json:"webhooks"`
+}
+
+type outboundWebhook struct {
+	ID     string   `json:"id"`
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events,omitempty"`
+}
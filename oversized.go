@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	oversizedReportsBlock = "block"
+	oversizedReportsSplit = "split"
+	oversizedReportsFile  = "file"
+
+	maxMessageLength = 2000
+
+	// The longest a report ID, a staff tag and the ID plus URL of an issue can be in the lines added to a posted report
+	maxReportIDLength       = 20
+	maxStaffTagLength       = 23
+	maxIssueReferenceLength = 300
+
+	// The least room a message of a text report should have for the report itself
+	minReportContentLength = 100
+)
+
+// The lines added to a posted report and the message of an attached report are configurable,
+// when those are too long there's no room left for the report itself
+func prepareOversizedReports() error {
+	if getReportContentLimit() < minReportContentLength {
+		return errors.New("the status and issue lines leave less than " + strconv.Itoa(minReportContentLength) + " characters for the report")
+	}
+
+	if config.OversizedReports == oversizedReportsFile && getReportContentLimit()-len(config.Messages.ReportAttachedAsFile) < minReportContentLength {
+		return errors.New("the report attached as file message leaves less than " + strconv.Itoa(minReportContentLength) + " characters for the summary")
+	}
+
+	return nil
+}
+
+// By default a report that's too large can't be submitted until the user shortens it
+func canPostOversizedReports() bool {
+	return config.OversizedReports == oversizedReportsSplit || config.OversizedReports == oversizedReportsFile
+}
+
+// The status and issue lines are added to the first message of a report after it's posted, this is the room they need
+func getPostedReportLinesLength() int {
+	statusLineLength := len(config.Messages.ReportStatusLine) + maxStaffTagLength
+	if len(config.Messages.ReportStatusLineTracker) > statusLineLength {
+		statusLineLength = len(config.Messages.ReportStatusLineTracker)
+	}
+
+	longestStatusName := 0
+	for _, name := range reportStatusNames {
+		if len(name) > longestStatusName {
+			longestStatusName = len(name)
+		}
+	}
+
+	// The issue line only ever shows up when there's an issue tracker
+	if config.IssueTracker.Type == "" {
+		return statusLineLength + longestStatusName
+	}
+
+	return statusLineLength + longestStatusName + len(config.Messages.IssueLinkLine) + maxIssueReferenceLength
+}
+
+// The length a message of a text report can have, without going over the limit of Discord once the lines are added
+func getReportContentLimit() int {
+	limit := maxMessageLength - getPostedReportLinesLength()
+	if config.ReportSafeMessageLength < limit {
+		return config.ReportSafeMessageLength
+	}
+
+	return limit
+}
+
+// Splits the report into messages at the question boundaries. Only an answer that doesn't fit in a message
+// on its own is cut in the middle, there isn't really another way around that.
+func splitReportContent(header string, parts []string, maxLength int) []string {
+	// Nothing would ever fit, cutting the report into pieces of no length at all would never end
+	if maxLength <= 0 {
+		return []string{header + strings.Join(parts, "")}
+	}
+
+	chunks := make([]string, 0, 2)
+	current := header
+	for _, part := range parts {
+		if len(current)+len(part) <= maxLength {
+			current += part
+			continue
+		}
+
+		if strings.TrimSpace(current) != "" {
+			chunks = append(chunks, current)
+		}
+
+		current = strings.TrimLeft(part, "\n")
+		for len(current) > maxLength {
+			cutIndex := findRuneBoundary(current, maxLength)
+			chunks = append(chunks, current[:cutIndex])
+			current = current[cutIndex:]
+		}
+	}
+
+	if strings.TrimSpace(current) != "" || len(chunks) == 0 {
+		chunks = append(chunks, current)
+	}
+
+	return chunks
+}
+
+// Makes sure we don't cut a character in half
+func findRuneBoundary(text string, maxLength int) int {
+	for index := maxLength; index > 0; index-- {
+		if utf8.RuneStart(text[index]) {
+			return index
+		}
+	}
+
+	return maxLength
+}
+
+// Spreads the fields over multiple embeds, the first one keeps the title, author and image of the report
+func splitReportEmbed(embed *discordgo.MessageEmbed) []*discordgo.MessageEmbed {
+	first := *embed
+	first.Fields = make([]*discordgo.MessageEmbedField, 0, maxEmbedFields)
+
+	embeds := []*discordgo.MessageEmbed{&first}
+	current := &first
+	for _, field := range embed.Fields {
+		fieldLength := utf8.RuneCountInString(field.Name) + utf8.RuneCountInString(field.Value)
+		if len(current.Fields) == maxEmbedFields || getEmbedLength(current)+fieldLength > maxEmbedTotalLength {
+			current = &discordgo.MessageEmbed{
+				Color:  embed.Color,
+				Fields: make([]*discordgo.MessageEmbedField, 0, maxEmbedFields),
+			}
+			embeds = append(embeds, current)
+		}
+
+		current.Fields = append(current.Fields, field)
+	}
+
+	return embeds
+}
+
+func generateReportFile(reportID uint64, content string) *discordgo.File {
+	name := "report.md"
+	if reportID != 0 {
+		name = "report-" + strconv.FormatUint(reportID, 10) + ".md"
+	}
+
+	return &discordgo.File{
+		Name:        name,
+		ContentType: "text/markdown",
+		Reader:      strings.NewReader(content),
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+type reportAttachment struct {
+	link        string
+	fileName    string
+	size        int
+	logFindings []logFinding
+
+	// The question that was being answered when the attachment was sent, -1 when it doesn't belong to a question
+	questionIndex int
+}
+
+// Attachments belong to the question the user is answering or editing, anything sent outside of that is general
+func getAttachmentQuestionIndex(report *reportData) int {
+	if report.isChoosingType || !report.shouldReadAnswer {
+		return -1
+	}
+
+	return int(report.currentQuestionIndex)
+}
+
+// Questions that are turned off by their conditions are never shown. Skipped questions are left out of the
+// final report unless there is a text configured to mark them with, the preview always shows them.
+func isQuestionShown(report *reportData, index int, isPreview bool) bool {
+	if !isQuestionActive(report, index) {
+		return false
+	}
+
+	return !report.data[index].skipped || isPreview || config.Messages.SkippedAnswer != ""
+}
+
+func getQuestionAttachmentLinks(report *reportData, questionIndex int) []string {
+	links := make([]string, 0)
+	for _, attachment := range report.attachments {
+		if attachment.questionIndex == questionIndex {
+			links = append(links, attachment.link)
+		}
+	}
+
+	return links
+}
+
+// The attachments of questions that aren't shown end up with the general attachments, that way nothing gets lost
+func getGeneralAttachmentLinks(report *reportData, isPreview bool) []string {
+	links := make([]string, 0)
+	for _, attachment := range report.attachments {
+		if attachment.questionIndex < 0 || attachment.questionIndex >= len(report.data) || !isQuestionShown(report, attachment.questionIndex, isPreview) {
+			links = append(links, attachment.link)
+		}
+	}
+
+	return links
+}
+
+func getAttachmentLinks(report *reportData) []string {
+	links := make([]string, len(report.attachments))
+	for index, attachment := range report.attachments {
+		links[index] = attachment.link
+	}
+
+	return links
+}
+
+func getAttachmentsSize(report *reportData) int {
+	size := 0
+	for _, attachment := range report.attachments {
+		size += attachment.size
+	}
+
+	return size
+}
+
+func generateAttachmentList(report *reportData) string {
+	if len(report.attachments) == 0 {
+		return config.Messages.NoAttachments
+	}
+
+	var builder strings.Builder
+	builder.WriteString(strings.ReplaceAll(config.Messages.AttachmentsListHeader, "{{REMOVE_ATTACHMENT_COMMAND}}", config.BotDMCommandPrefix+config.BotDMCommandRemoveAttachment))
+	for index, attachment := range report.attachments {
+		builder.WriteString("\n**")
+		builder.WriteString(strconv.Itoa(index + 1))
+		builder.WriteString(".** ")
+		builder.WriteString(formatAttachmentLink(attachment.link))
+
+		if attachment.questionIndex >= 0 && attachment.questionIndex < len(report.data) {
+			builder.WriteString(" - ")
+			builder.WriteString(formatQuestionLabel(report.data[attachment.questionIndex].question.reportQuestion))
+		}
+	}
+
+	return builder.String()
+}
+
+func handleRemoveAttachment(report *reportData, userID, content string) {
+	split := strings.Split(strings.TrimSpace(content), " ")
+	if len(split) != 2 {
+		sendMessageToDM(formatInvalidAttachmentNumber(report), userID)
+		return
+	}
+
+	number, parseErr := strconv.Atoi(split[1])
+	if parseErr != nil || number < 1 || number > len(report.attachments) {
+		sendMessageToDM(formatInvalidAttachmentNumber(report), userID)
+		return
+	}
+
+	markReportAsActive(report)
+
+	report.attachments = append(report.attachments[:number-1], report.attachments[number:]...)
+	sendMessageToDM(strings.ReplaceAll(config.Messages.AttachmentRemoved, "{{NUMBER}}", strconv.Itoa(number)), userID)
+
+	// The preview in the submit menu should match the report again
+	if report.isInSubmitMenu {
+		handleSubmittingProcess(report, userID)
+	}
+}
+
+func formatInvalidAttachmentNumber(report *reportData) string {
+	baseString := strings.ReplaceAll(config.Messages.InvalidAttachmentNumber, "{{ATTACHMENTS_COMMAND}}", config.BotDMCommandPrefix+config.BotDMCommandAttachments)
+	return strings.ReplaceAll(baseString, "{{ATTACHMENT_COUNT}}", strconv.Itoa(len(report.attachments)))
+}
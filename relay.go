@@ -0,0 +1,329 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	askReporterButtonPrefix = "report_ask:"
+	askReporterModalPrefix  = "report_ask_modal:"
+	askReporterInputID      = "relay_message"
+	maxRelayMessageLength   = 2000
+)
+
+var (
+	// The reporters that are talking to the staff about one of their submitted reports, keyed by their user ID
+	currentRelayConversations = make(map[string]*relayConversation)
+	currentRelaysMutex        = new(sync.Mutex)
+)
+
+type relayConversation struct {
+	reportID        uint64
+	threadID        string
+	channelID       string
+	messageID       string
+	lastInteraction time.Time
+}
+
+func generateAskReporterComponent(reportID uint64) discordgo.MessageComponent {
+	return discordgo.ActionsRow{
+		Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				CustomID: askReporterButtonPrefix + strconv.FormatUint(reportID, 10),
+				Label:    "Ask Reporter",
+				Style:    discordgo.SecondaryButton,
+			},
+		},
+	}
+}
+
+// Staff members can talk to the reporter by starting their message with the relay prefix in the thread of a report
+func handleStaffRelayMessage(message *discordgo.MessageCreate) {
+	if message.Member == nil || config.StaffRelayPrefix == "" || !strings.HasPrefix(message.Content, config.StaffRelayPrefix) {
+		return
+	}
+
+	if !isStaffMember(message.Member) {
+		return
+	}
+
+	content := strings.TrimSpace(strings.TrimPrefix(message.Content, config.StaffRelayPrefix))
+	if content == "" {
+		return
+	}
+
+	archived, loadErr := reportStorage.loadArchivedReportByThread(message.ChannelID)
+	if loadErr != nil {
+		if loadErr != errReportNotFound {
+			logStorageError("load the archived report of thread "+message.ChannelID, loadErr)
+		}
+		return
+	}
+
+	if !relayMessageToReporter(archived, content) {
+		botSession.ChannelMessageSendReply(message.ChannelID, config.Messages.RelayFailed, message.Reference())
+		return
+	}
+
+	botSession.MessageReactionAdd(message.ChannelID, message.ID, "✅")
+}
+
+func handleAskReporterButton(session *discordgo.Session, interaction *discordgo.InteractionCreate, customID string) {
+	if interaction.Member == nil || !isStaffMember(interaction.Member) {
+		respondEphemeral(session, interaction, config.Messages.InteractionNotAllowed)
+		return
+	}
+
+	reportID := strings.TrimPrefix(customID, askReporterButtonPrefix)
+	session.InteractionRespond(interaction.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: askReporterModalPrefix + reportID,
+			Title:    truncateText("Ask the reporter of #"+reportID, maxModalTitleLength),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:  askReporterInputID,
+							Label:     "Message",
+							Style:     discordgo.TextInputParagraph,
+							Required:  true,
+							MaxLength: maxRelayMessageLength,
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
+func handleAskReporterModalSubmit(session *discordgo.Session, interaction *discordgo.InteractionCreate) {
+	data := interaction.ModalSubmitData()
+	reportID, parseErr := strconv.ParseUint(strings.TrimPrefix(data.CustomID, askReporterModalPrefix), 10, 64)
+	if parseErr != nil {
+		return
+	}
+
+	if interaction.Member == nil || !isStaffMember(interaction.Member) {
+		respondEphemeral(session, interaction, config.Messages.InteractionNotAllowed)
+		return
+	}
+
+	archived, loadErr := reportStorage.loadArchivedReport(reportID)
+	if loadErr != nil {
+		if loadErr != errReportNotFound {
+			logStorageError("load archived report #"+strconv.FormatUint(reportID, 10), loadErr)
+		}
+		respondEphemeral(session, interaction, replaceReportIDPlaceholder(config.Messages.ReportNotFound, reportID))
+		return
+	}
+
+	content := strings.TrimSpace(readModalValues(data)[askReporterInputID])
+	if content == "" || !relayMessageToReporter(archived, content) {
+		respondEphemeral(session, interaction, config.Messages.RelayFailed)
+		return
+	}
+
+	// The question is also posted in the thread, that way the other staff members know what has been asked
+	askedMessage := strings.ReplaceAll(config.Messages.RelayAskedByStaff, "{{STAFF_TAG}}", "<@"+interaction.Member.User.ID+">")
+	askedMessage = strings.ReplaceAll(askedMessage, "{{MESSAGE}}", content)
+	sendRelayMessageToReport(archived.ThreadID, archived.ChannelID, archived.MessageID, askedMessage, nil)
+
+	respondEphemeral(session, interaction, config.Messages.RelaySent)
+}
+
+// Sends the message of the staff to the reporter, from then on everything the reporter sends in their DMs goes to the report
+func relayMessageToReporter(archived *archivedReport, content string) (succeeded bool) {
+	reportTypeName := archived.ReportType
+	if chosenType := findReportType(archived.ReportType); chosenType != nil {
+		reportTypeName = chosenType.Name
+	}
+
+	baseString := replaceReportIDPlaceholder(config.Messages.RelayToReporter, archived.ID)
+	baseString = strings.ReplaceAll(baseString, "{{REPORT_TYPE}}", reportTypeName)
+	baseString = strings.ReplaceAll(baseString, "{{CANCEL_COMMAND}}", config.BotDMCommandPrefix+config.BotDMCommandCancel)
+	baseString = strings.ReplaceAll(baseString, "{{MESSAGE}}", content)
+
+	if !sendMessageToDM(baseString, archived.ReporterID) {
+		return false
+	}
+
+	conversation := &relayConversation{
+		reportID:        archived.ID,
+		threadID:        archived.ThreadID,
+		channelID:       archived.ChannelID,
+		messageID:       archived.MessageID,
+		lastInteraction: time.Now(),
+	}
+
+	currentRelaysMutex.Lock()
+	currentRelayConversations[archived.ReporterID] = conversation
+	currentRelaysMutex.Unlock()
+
+	saveRelayConversationToStorage(archived.ReporterID, conversation)
+	return true
+}
+
+// Relays a Direct Message of the reporter to their report, returns false when the user isn't talking to the staff
+func handleReporterRelayMessage(message *discordgo.MessageCreate) (handled bool) {
+	currentRelaysMutex.Lock()
+	conversation, ok := currentRelayConversations[message.Author.ID]
+	if !ok {
+		currentRelaysMutex.Unlock()
+		return false
+	}
+
+	if strings.ToLower(strings.TrimSpace(message.Content)) == config.BotDMCommandPrefix+config.BotDMCommandCancel {
+		delete(currentRelayConversations, message.Author.ID)
+		currentRelaysMutex.Unlock()
+
+		deleteRelayConversationFromStorage(message.Author.ID)
+		sendMessageToDM(config.Messages.RelayEnded, message.Author.ID)
+		return true
+	}
+
+	conversation.lastInteraction = time.Now()
+	currentRelaysMutex.Unlock()
+
+	saveRelayConversationToStorage(message.Author.ID, conversation)
+
+	// The attachment links of Discord expire, so the attachments are uploaded again together with the relayed message.
+	// Attachments that can't be uploaded are linked instead.
+	var builder strings.Builder
+	builder.WriteString(strings.ReplaceAll(message.Content, "@", "at"))
+
+	attachments := make([]rehostedAttachment, 0, len(message.Attachments))
+	uploadSize := 0
+	for index, attachment := range message.Attachments {
+		if uploadSize+attachment.Size <= maxDiscordUploadSize {
+			downloaded, downloadErr := downloadAttachment(attachment.URL, index)
+			if downloadErr == nil && uploadSize+len(downloaded.data) <= maxDiscordUploadSize {
+				attachments = append(attachments, downloaded)
+				uploadSize += len(downloaded.data)
+				continue
+			}
+		}
+
+		builder.WriteString("\n")
+		builder.WriteString(attachment.URL)
+	}
+
+	relayedMessage := strings.ReplaceAll(config.Messages.RelayToReport, "{{USER_TAG}}", "<@"+message.Author.ID+">")
+	relayedMessage = strings.ReplaceAll(relayedMessage, "{{MESSAGE}}", strings.TrimSpace(builder.String()))
+
+	if sendRelayMessageToReport(conversation.threadID, conversation.channelID, conversation.messageID, relayedMessage, generateAttachmentFiles(attachments)) {
+		botSession.MessageReactionAdd(message.ChannelID, message.ID, "✅")
+	} else {
+		sendMessageToDM(config.Messages.RelayFailed, message.Author.ID)
+	}
+
+	return true
+}
+
+// Messages go in the thread of the report, or as a reply to the report when it doesn't have a thread
+func sendRelayMessageToReport(threadID, channelID, messageID, content string, files []*discordgo.File) (succeeded bool) {
+	messageSend := &discordgo.MessageSend{
+		Content: truncateText(content, maxRelayMessageLength),
+		Files:   files,
+	}
+
+	if threadID == "" {
+		messageSend.Reference = &discordgo.MessageReference{ChannelID: channelID, MessageID: messageID}
+	} else {
+		channelID = threadID
+	}
+
+	_, sendErr := botSession.ChannelMessageSendComplex(channelID, messageSend)
+	return sendErr == nil
+}
+
+func checkRelayConversationCleanup(currentTime time.Time) {
+	currentRelaysMutex.Lock()
+
+	// Older configs don't have a separate timeout for conversations with the staff
+	timeoutMinutes := config.RelayTimeoutMinutes
+	if timeoutMinutes == 0 {
+		timeoutMinutes = config.ReportTimeoutMinutes
+	}
+
+	markedForRemoval := make([]string, 0)
+
+	for userID, conversation := range currentRelayConversations {
+		if currentTime.After(conversation.lastInteraction.Add(time.Duration(timeoutMinutes) * time.Minute)) {
+			markedForRemoval = append(markedForRemoval, userID)
+		}
+	}
+
+	for _, userID := range markedForRemoval {
+		delete(currentRelayConversations, userID)
+	}
+	currentRelaysMutex.Unlock()
+
+	for _, userID := range markedForRemoval {
+		deleteRelayConversationFromStorage(userID)
+		sendMessageToDM(config.Messages.RelayEnded, userID)
+	}
+}
+
+// Loads the conversations with the staff that were still going on when the bot went down
+func restoreRelayConversations() {
+	storedConversations, loadErr := reportStorage.loadRelayConversations()
+	if loadErr != nil {
+		logStorageError("load the relay conversations", loadErr)
+		return
+	}
+
+	currentRelaysMutex.Lock()
+	defer currentRelaysMutex.Unlock()
+
+	for userID, stored := range storedConversations {
+		currentRelayConversations[userID] = &relayConversation{
+			reportID:  stored.ReportID,
+			threadID:  stored.ThreadID,
+			channelID: stored.ChannelID,
+			messageID: stored.MessageID,
+			// The downtime of the bot shouldn't count towards the timeout of the conversation
+			lastInteraction: time.Now(),
+		}
+	}
+}
+
+// The conversation could have ended in the meantime, in that case there is nothing to save
+func saveRelayConversationToStorage(userID string, conversation *relayConversation) {
+	currentRelaysMutex.Lock()
+	defer currentRelaysMutex.Unlock()
+
+	if current, ok := currentRelayConversations[userID]; !ok || current != conversation {
+		return
+	}
+
+	stored := &storedRelayConversation{
+		ReportID:        conversation.reportID,
+		ThreadID:        conversation.threadID,
+		ChannelID:       conversation.channelID,
+		MessageID:       conversation.messageID,
+		LastInteraction: conversation.lastInteraction,
+	}
+	if saveErr := reportStorage.saveRelayConversation(userID, stored); saveErr != nil {
+		logStorageError("save the relay conversation of user "+userID, saveErr)
+	}
+}
+
+// A new conversation with the user could have been started in the meantime, that one shouldn't be deleted
+func deleteRelayConversationFromStorage(userID string) {
+	currentRelaysMutex.Lock()
+	defer currentRelaysMutex.Unlock()
+
+	if _, ok := currentRelayConversations[userID]; ok {
+		return
+	}
+
+	if deleteErr := reportStorage.deleteRelayConversation(userID); deleteErr != nil {
+		logStorageError("delete the relay conversation of user "+userID, deleteErr)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultReportTypeID   = "bug"
+	defaultReportTypeName = "Bug Report"
+)
+
+// Older configs only have one global list of questions, those are turned into a single report type.
+// Report types that leave out their channel, cooldown or end message fall back to the global values.
+func prepareReportTypes() error {
+	if len(config.ReportTypes) == 0 {
+		config.ReportTypes = []*reportType{
+			{
+				ID:        defaultReportTypeID,
+				Name:      defaultReportTypeName,
+				Questions: config.Questions,
+			},
+		}
+	}
+
+	seenIDs := make(map[string]bool)
+	for _, value := range config.ReportTypes {
+		value.ID = strings.ToLower(value.ID)
+		if value.ID == "" || seenIDs[value.ID] {
+			return errors.New("every report type needs an unique id, \"" + value.ID + "\" is empty or used more than once")
+		}
+		seenIDs[value.ID] = true
+
+		if len(value.Questions) == 0 {
+			return errors.New("report type \"" + value.ID + "\" doesn't have any questions")
+		}
+
+		// There are no earlier answers the first question could look at, its conditions could never be met.
+		// Without this rule a report could end up without a single question that can be asked.
+		if len(value.Questions[0].Conditions) > 0 {
+			return errors.New("the first question of report type \"" + value.ID + "\" can't have conditions")
+		}
+
+		if value.ChannelID == "" {
+			value.ChannelID = config.ReportChannelID
+		}
+		if value.CooldownMinutes == nil {
+			value.CooldownMinutes = &config.ReportCooldownMinutes
+		}
+		if value.EndMessage == "" {
+			value.EndMessage = config.Messages.EndMessageReport
+		}
+
+		if conditionsErr := prepareQuestionConditions(value); conditionsErr != nil {
+			return conditionsErr
+		}
+
+		if validatorErr := prepareAnswerValidators(value.Questions); validatorErr != nil {
+			return validatorErr
+		}
+
+		if colorErr := prepareQuestionColors(value.Questions); colorErr != nil {
+			return colorErr
+		}
+
+		if routingErr := prepareRoutingRules(value); routingErr != nil {
+			return routingErr
+		}
+
+		colorValue, colorErr := parseColor(value.Color)
+		if colorErr != nil {
+			return colorErr
+		}
+		value.colorValue = colorValue
+	}
+
+	return nil
+}
+
+func findReportType(reportTypeID string) *reportType {
+	reportTypeID = strings.ToLower(reportTypeID)
+	for _, value := range config.ReportTypes {
+		if value.ID == reportTypeID {
+			return value
+		}
+	}
+
+	return nil
+}
+
+// A user can choose a report type by its number in the list, its name or its ID
+func parseReportTypeChoice(content string) *reportType {
+	formattedContent := strings.ToLower(strings.TrimSpace(content))
+
+	if number, parseErr := strconv.Atoi(formattedContent); parseErr == nil {
+		if number > 0 && number <= len(config.ReportTypes) {
+			return config.ReportTypes[number-1]
+		}
+		return nil
+	}
+
+	for _, value := range config.ReportTypes {
+		if strings.ToLower(value.Name) == formattedContent {
+			return value
+		}
+	}
+
+	return findReportType(formattedContent)
+}
+
+// Builds the questions of the chosen report type and moves the report to its first question
+func startReportQuestions(report *reportData, chosenType *reportType) {
+	report.reportType = chosenType
+	report.data = buildReportQuestions(chosenType)
+	report.isChoosingType = false
+	report.shouldReadAnswer = true
+	report.currentQuestionIndex = 0
+}
+
+func handleReportTypeChoice(report *reportData, userID, content string) {
+	chosenType := parseReportTypeChoice(content)
+	if chosenType == nil {
+		sendMessageToDM(config.Messages.InvalidReportType+generateReportTypeList(), userID)
+		return
+	}
+
+	if isUserOnReportCooldown(userID, chosenType.ID) {
+		sendMessageToDM(config.Messages.ReportCooldown, userID)
+		return
+	}
+
+	markReportAsActive(report)
+	startReportQuestions(report, chosenType)
+	sendReportQuestion(report, userID, false)
+}
+
+func sendReportTypePrompt(userID string, firstMessage bool) (succeeded bool) {
+	content := ""
+	if firstMessage {
+		content = formatWelcomeMessage()
+	}
+
+	return sendMessageToDM(content+config.Messages.ChooseReportType+generateReportTypeList(), userID)
+}
+
+func generateReportTypeList() string {
+	var builder strings.Builder
+	for index, value := range config.ReportTypes {
+		builder.WriteString("\n**")
+		builder.WriteString(strconv.Itoa(index + 1))
+		builder.WriteString(".** ")
+		builder.WriteString(value.Name)
+		if value.Description != "" {
+			builder.WriteString(" - ")
+			builder.WriteString(value.Description)
+		}
+	}
+
+	return builder.String()
+}
+
+type reportType struct {
+	ID                string           `json:"id"`
+	Name              string           `json:"name"`
+	Description       string           `json:"description,omitempty"`
+	ChannelID         string           `json:"channel_id,omitempty"`
+	CooldownMinutes   *uint            `json:"cooldown_minutes,omitempty"`
+	EndMessage        string           `json:"end_message,omitempty"`
+	Color             string           `json:"color,omitempty"`
+	TitleQuestionID   string           `json:"title_question_id,omitempty"`
+	RoutingRules      []routingRule    `json:"routing_rules,omitempty"`
+	RequireScreenshot bool             `json:"require_screenshot,omitempty"`
+	Questions         []reportQuestion `json:"questions"`
+
+	colorValue int
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+// Goes through the routing rules of the report type in order, the first rule that matches decides where the report
+// is posted. Rules with "continue" enabled add their channels and let the next rules be checked as well.
+// When no rule matches at all the report ends up in the channel of the report type.
+func findReportChannels(report *reportData) []string {
+	channelIDs := make([]string, 0, 1)
+	for _, rule := range report.reportType.RoutingRules {
+		if !rule.isMetBy(report) {
+			continue
+		}
+
+		for _, channelID := range rule.ChannelIDs {
+			if !containsString(channelIDs, channelID) {
+				channelIDs = append(channelIDs, channelID)
+			}
+		}
+
+		if !rule.Continue {
+			break
+		}
+	}
+
+	if len(channelIDs) == 0 {
+		channelIDs = append(channelIDs, report.reportType.ChannelID)
+	}
+
+	return channelIDs
+}
+
+// All conditions of a rule have to be met, a condition on a question that wasn't asked is never met
+func (rule routingRule) isMetBy(report *reportData) bool {
+	for _, condition := range rule.Conditions {
+		index := findQuestionIndexByID(report, condition.QuestionID, len(report.data))
+		if index == -1 || !isQuestionActive(report, index) || report.data[index].skipped {
+			return false
+		}
+
+		answer := report.data[index].answer
+		if !condition.isMetBy(answer) {
+			return false
+		}
+
+		if len(condition.Contains) > 0 && !containsKeyword(answer, condition.Contains) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsKeyword(answer string, keywords []string) bool {
+	formattedAnswer := strings.ToLower(answer)
+	for _, keyword := range keywords {
+		if strings.Contains(formattedAnswer, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, current := range values {
+		if current == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func prepareRoutingRules(chosenType *reportType) error {
+	for _, rule := range chosenType.RoutingRules {
+		if len(rule.ChannelIDs) == 0 {
+			return errors.New("every routing rule of report type \"" + chosenType.ID + "\" needs at least one channel")
+		}
+
+		for _, condition := range rule.Conditions {
+			if condition.QuestionID == "" {
+				return errors.New("every routing condition of report type \"" + chosenType.ID + "\" needs a question id")
+			}
+			if findConfigQuestionIndex(chosenType, condition.QuestionID) == -1 {
+				return errors.New("a routing condition of report type \"" + chosenType.ID + "\" points to unknown question \"" + condition.QuestionID + "\"")
+			}
+		}
+	}
+
+	return nil
+}
+
+type routingRule struct {
+	Conditions []routingCondition `json:"conditions"`
+	ChannelIDs []string           `json:"channel_ids"`
+	Continue   bool               `json:"continue,omitempty"`
+}
+
+// A routing condition works like the condition of a question, with keywords to look for in the answer on top of that
+type routingCondition struct {
+	questionCondition
+	Contains []string `json:"contains,omitempty"`
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	s3SignedHeaders = "content-type;host;x-amz-content-sha256;x-amz-date"
+	s3DateLayout    = "20060102T150405Z"
+	s3DayLayout     = "20060102"
+)
+
+// Uploads an attachment to an S3 compatible bucket. The request is signed with AWS Signature Version 4,
+// which is all that's needed for a single upload, so we don't need a complete SDK for this.
+func uploadAttachmentToS3(bucketConfig s3Config, key string, attachment rehostedAttachment) (string, error) {
+	objectURL := strings.TrimSuffix(bucketConfig.Endpoint, "/") + "/" + bucketConfig.Bucket + "/" + key
+	parsedURL, parseErr := url.Parse(objectURL)
+	if parseErr != nil {
+		return "", parseErr
+	}
+
+	contentType := attachment.contentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	request, requestErr := http.NewRequest(http.MethodPut, objectURL, bytes.NewReader(attachment.data))
+	if requestErr != nil {
+		return "", requestErr
+	}
+
+	now := time.Now().UTC()
+	payloadHash := hashSHA256Hex(attachment.data)
+	request.Header.Set("Content-Type", contentType)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	request.Header.Set("X-Amz-Date", now.Format(s3DateLayout))
+	request.Header.Set("Authorization", signS3Request(bucketConfig, parsedURL, contentType, payloadHash, now))
+
+	response, uploadErr := attachmentHTTPClient.Do(request)
+	if uploadErr != nil {
+		return "", uploadErr
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", errors.New("unexpected status " + response.Status + " while uploading " + key)
+	}
+
+	if bucketConfig.PublicURL != "" {
+		return strings.TrimSuffix(bucketConfig.PublicURL, "/") + "/" + key, nil
+	}
+
+	return objectURL, nil
+}
+
+func signS3Request(bucketConfig s3Config, objectURL *url.URL, contentType, payloadHash string, now time.Time) string {
+	amazonDate := now.Format(s3DateLayout)
+	scope := now.Format(s3DayLayout) + "/" + bucketConfig.Region + "/s3/aws4_request"
+
+	canonicalHeaders := "content-type:" + contentType + "\n" +
+		"host:" + objectURL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amazonDate + "\n"
+	canonicalRequest := http.MethodPut + "\n" + objectURL.EscapedPath() + "\n\n" + canonicalHeaders + "\n" + s3SignedHeaders + "\n" + payloadHash
+	stringToSign := "AWS4-HMAC-SHA256\n" + amazonDate + "\n" + scope + "\n" + hashSHA256Hex([]byte(canonicalRequest))
+
+	signingKey := hmacSHA256([]byte("AWS4"+bucketConfig.SecretAccessKey), now.Format(s3DayLayout))
+	signingKey = hmacSHA256(signingKey, bucketConfig.Region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	return "AWS4-HMAC-SHA256 Credential=" + bucketConfig.AccessKeyID + "/" + scope + ", SignedHeaders=" + s3SignedHeaders + ", Signature=" + signature
+}
+
+func hashSHA256Hex(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// The bucket is always addressed path style, that's the one every S3 compatible service supports
+type s3Config struct {
+	Endpoint        string `json:"endpoint,omitempty"`
+	Region          string `json:"region,omitempty"`
+	Bucket          string `json:"bucket,omitempty"`
+	AccessKeyID     string `json:"access_key_id,omitempty"`
+	SecretAccessKey string `json:"secret_access_key,omitempty"`
+	PublicURL       string `json:"public_url,omitempty"`
+}
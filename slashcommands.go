@@ -0,0 +1,200 @@
+package main
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	slashCommandReport       = "report"
+	slashCommandReportCancel = "report-cancel"
+	slashCommandReportStatus = "report-status"
+	slashCommandMyReports    = "my-reports"
+
+	maxListedReports = 10
+)
+
+// Registers the slash commands, when a guild ID is configured they are only registered for that guild.
+// Guild commands show up right away while global commands can take up to an hour.
+func registerSlashCommands() {
+	typeChoices := make([]*discordgo.ApplicationCommandOptionChoice, len(config.ReportTypes))
+	for index, value := range config.ReportTypes {
+		typeChoices[index] = &discordgo.ApplicationCommandOptionChoice{
+			Name:  value.Name,
+			Value: value.ID,
+		}
+	}
+
+	commands := []*discordgo.ApplicationCommand{
+		{
+			Name:        slashCommandReport,
+			Description: "Start a new report in your Direct Messages",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "type",
+					Description: "The kind of report you want to make",
+					Choices:     typeChoices,
+				},
+			},
+		},
+		{
+			Name:        slashCommandReportCancel,
+			Description: "Cancel the report you're currently making",
+		},
+		{
+			Name:        slashCommandReportStatus,
+			Description: "Look up the status of a submitted report",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "id",
+					Description: "The number of the report",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:        slashCommandMyReports,
+			Description: "List the reports you've submitted",
+		},
+	}
+
+	_, registerErr := botSession.ApplicationCommandBulkOverwrite(botSession.State.User.ID, config.GuildID, commands)
+	if registerErr != nil {
+		log.Println("Unable to register the slash commands: " + registerErr.Error())
+	}
+}
+
+func handleSlashCommand(session *discordgo.Session, interaction *discordgo.InteractionCreate) {
+	user := getInteractionUser(interaction)
+	if user == nil {
+		return
+	}
+
+	data := interaction.ApplicationCommandData()
+	switch data.Name {
+	case slashCommandReport:
+		reportTypeID := ""
+		if len(data.Options) > 0 {
+			reportTypeID = data.Options[0].StringValue()
+		}
+
+		if tryOpenReportModal(session, interaction, user.ID, reportTypeID) {
+			return
+		}
+
+		// Starting the conversation sends a Direct Message first, the reply depends on how that went
+		session.InteractionRespond(interaction.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Flags: discordgo.MessageFlagsEphemeral,
+			},
+		})
+
+		response := startNewReportConversation(user.ID, "", reportTypeID)
+		session.InteractionResponseEdit(interaction.Interaction, &discordgo.WebhookEdit{
+			Content: &response,
+		})
+	case slashCommandReportCancel:
+		currentReportsMutex.RLock()
+		report, ok := currentOngoingReports[user.ID]
+		currentReportsMutex.RUnlock()
+		if !ok {
+			respondEphemeral(session, interaction, config.Messages.NoOngoingReport)
+			return
+		}
+
+		// The report could have been submitted or timed out while waiting for its lock
+		report.lock.Lock()
+		removed := removeReportAndUserFromCache(user.ID, report)
+		if removed {
+			fireOngoingReportEvent(reportEventCancelled, user.ID, report)
+		}
+		report.lock.Unlock()
+
+		if !removed {
+			respondEphemeral(session, interaction, config.Messages.NoOngoingReport)
+			return
+		}
+
+		respondEphemeral(session, interaction, config.Messages.CancellingReport)
+	case slashCommandReportStatus:
+		if len(data.Options) == 0 {
+			return
+		}
+
+		respondEphemeral(session, interaction, generateReportStatusOverview(uint64(data.Options[0].IntValue()), user.ID, interaction.Member))
+	case slashCommandMyReports:
+		respondEphemeral(session, interaction, generateMyReportsOverview(user.ID))
+	}
+}
+
+// Only the reporter and the staff are allowed to look at a report, for everyone else it simply doesn't exist
+func generateReportStatusOverview(reportID uint64, userID string, member *discordgo.Member) string {
+	archived, loadErr := reportStorage.loadArchivedReport(reportID)
+	if loadErr != nil {
+		if loadErr != errReportNotFound {
+			logStorageError("load archived report #"+strconv.FormatUint(reportID, 10), loadErr)
+		}
+		return replaceReportIDPlaceholder(config.Messages.ReportNotFound, reportID)
+	}
+
+	if archived.ReporterID != userID && (member == nil || !isStaffMember(member)) {
+		return replaceReportIDPlaceholder(config.Messages.ReportNotFound, reportID)
+	}
+
+	overview := formatReportOverview(config.Messages.ReportStatusOverview, archived)
+	if archived.StaffNote != "" {
+		overview += strings.ReplaceAll(config.Messages.StaffNote, "{{STAFF_NOTE}}", archived.StaffNote)
+	}
+
+	return overview
+}
+
+func generateMyReportsOverview(userID string) string {
+	reports, loadErr := reportStorage.loadArchivedReportsByReporter(userID)
+	if loadErr != nil {
+		logStorageError("load the archived reports of user "+userID, loadErr)
+		return config.Messages.NoSubmittedReports
+	}
+
+	if len(reports) == 0 {
+		return config.Messages.NoSubmittedReports
+	}
+
+	// Only the most recent reports are shown, otherwise we could easily go over the message limit
+	if len(reports) > maxListedReports {
+		reports = reports[len(reports)-maxListedReports:]
+	}
+
+	var builder strings.Builder
+	builder.WriteString(config.Messages.MyReportsHeader)
+	for index := len(reports) - 1; index >= 0; index-- {
+		builder.WriteString("\n")
+		builder.WriteString(formatReportOverview(config.Messages.MyReportsLine, reports[index]))
+	}
+
+	return builder.String()
+}
+
+func formatReportOverview(template string, archived *archivedReport) string {
+	reportTypeName := archived.ReportType
+	if chosenType := findReportType(archived.ReportType); chosenType != nil {
+		reportTypeName = chosenType.Name
+	}
+
+	status := archived.Status
+	if status == "" {
+		status = reportStatusOpen
+	}
+
+	overview := replaceReportIDPlaceholder(template, archived.ID)
+	overview = strings.ReplaceAll(overview, "{{REPORT_TYPE}}", reportTypeName)
+	overview = strings.ReplaceAll(overview, "{{STATUS}}", reportStatusNames[status])
+	overview = strings.ReplaceAll(overview, "{{SUBMITTED_AT}}", "<t:"+strconv.FormatInt(archived.SubmittedAt.Unix(), 10)+":f>")
+	return overview
+}
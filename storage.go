@@ -0,0 +1,899 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	storageTypeFile = "file"
+	storageTypeBolt = "bolt"
+
+	defaultFileStoragePath      = "./data/ongoing_reports.json"
+	defaultBoltStoragePath      = "./data/reports.db"
+	defaultArchiveDirectoryPath = "./data/archive"
+
+	// Stored in the archive directory, the dot keeps it apart from the archived reports
+	lastReportIDFileName = ".last_report_id"
+
+	// Stored next to the file with the ongoing reports
+	relayConversationsFileName = "relay_conversations.json"
+)
+
+var (
+	ongoingReportsBucket  = []byte("ongoing_reports")
+	archivedReportsBucket = []byte("archived_reports")
+	reportThreadsBucket   = []byte("report_threads")
+	reportIssuesBucket    = []byte("report_issues")
+	relaysBucket          = []byte("relay_conversations")
+)
+
+var reportStorage reportStore
+
+// Keeps the changes to the ongoing reports in the storage in order, without holding the global report lock
+var ongoingStorageMutex = new(sync.Mutex)
+
+var errReportNotFound = errors.New("report not found")
+
+// reportStore is the persistence layer used to survive restarts of the bot.
+// Every implementation should be safe to use from multiple goroutines.
+type reportStore interface {
+	saveOngoingReport(userID string, report *storedReport) error
+	deleteOngoingReport(userID string) error
+	loadOngoingReports() (map[string]*storedReport, error)
+	nextReportID() (uint64, error)
+	saveArchivedReport(report *archivedReport) error
+	loadArchivedReport(reportID uint64) (*archivedReport, error)
+	loadArchivedReportsByReporter(userID string) ([]*archivedReport, error)
+	loadArchivedReportByThread(threadID string) (*archivedReport, error)
+	loadArchivedReportByIssue(issueURL string) (*archivedReport, error)
+	saveRelayConversation(userID string, conversation *storedRelayConversation) error
+	deleteRelayConversation(userID string) error
+	loadRelayConversations() (map[string]*storedRelayConversation, error)
+	close() error
+}
+
+// Older configs don't have a storage section, those get the default paths
+func openReportStore(storageConfig storageConfig) (reportStore, error) {
+	switch storageConfig.Type {
+	case storageTypeFile, "":
+		if storageConfig.Path == "" {
+			storageConfig.Path = defaultFileStoragePath
+		}
+		if storageConfig.ArchiveDirectory == "" {
+			storageConfig.ArchiveDirectory = defaultArchiveDirectoryPath
+		}
+	case storageTypeBolt:
+		if storageConfig.Path == "" {
+			storageConfig.Path = defaultBoltStoragePath
+		}
+	default:
+		return nil, errors.New("unknown storage type \"" + storageConfig.Type + "\"")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filepath.FromSlash(storageConfig.Path)), 0755); err != nil {
+		return nil, err
+	}
+
+	if storageConfig.Type == storageTypeBolt {
+		return newBoltReportStore(filepath.FromSlash(storageConfig.Path))
+	}
+
+	return newFileReportStore(filepath.FromSlash(storageConfig.Path), filepath.FromSlash(storageConfig.ArchiveDirectory))
+}
+
+// Saves the current state of an ongoing report so it can be restored later on.
+// WARNING! The lock of the report has to be held by the caller!
+func persistOngoingReport(userID string, report *reportData) {
+	ongoingStorageMutex.Lock()
+	defer ongoingStorageMutex.Unlock()
+
+	// The report could have been submitted, cancelled or timed out in the meantime, in that case there is nothing to save
+	if getOngoingReport(userID) != report {
+		return
+	}
+
+	if saveErr := reportStorage.saveOngoingReport(userID, newStoredReport(report)); saveErr != nil {
+		logStorageError("save the ongoing report of user "+userID, saveErr)
+	}
+}
+
+// A new report of the user could have been started in the meantime, that one shouldn't be deleted
+func removeOngoingReportFromStorage(userID string) {
+	ongoingStorageMutex.Lock()
+	defer ongoingStorageMutex.Unlock()
+
+	if getOngoingReport(userID) != nil {
+		return
+	}
+
+	deleteOngoingReportFromStorage(userID)
+}
+
+// The global lock is only held while looking at the report, never while the storage is busy.
+// Every change to the storage checks the current report again, that way the storage always ends up matching it.
+func getOngoingReport(userID string) *reportData {
+	currentReportsMutex.RLock()
+	defer currentReportsMutex.RUnlock()
+
+	return currentOngoingReports[userID]
+}
+
+func deleteOngoingReportFromStorage(userID string) {
+	if deleteErr := reportStorage.deleteOngoingReport(userID); deleteErr != nil {
+		logStorageError("delete the ongoing report of user "+userID, deleteErr)
+	}
+}
+
+// Loads all the reports that were still in progress when the bot went down.
+// Reports that don't match the current questions anymore are thrown away since the answers can't be trusted.
+func restoreOngoingReports() (restoredUserIDs, droppedUserIDs []string) {
+	storedReports, loadErr := reportStorage.loadOngoingReports()
+	if loadErr != nil {
+		logStorageError("load the ongoing reports", loadErr)
+		return nil, nil
+	}
+
+	currentReportsMutex.Lock()
+	for userID, stored := range storedReports {
+		report := stored.toReportData()
+		if report == nil {
+			droppedUserIDs = append(droppedUserIDs, userID)
+			continue
+		}
+
+		currentOngoingReports[userID] = report
+		restoredUserIDs = append(restoredUserIDs, userID)
+	}
+	currentReportsMutex.Unlock()
+
+	for _, userID := range droppedUserIDs {
+		removeOngoingReportFromStorage(userID)
+	}
+
+	return restoredUserIDs, droppedUserIDs
+}
+
+// Lets the users know their report survived a restart and asks the question they were at again.
+// The users whose report couldn't be restored are told they have to start over.
+func notifyRestoredReports(restoredUserIDs, droppedUserIDs []string) {
+	for _, userID := range droppedUserIDs {
+		sendMessageToDM(config.Messages.ReportNotRestored, userID)
+	}
+
+	for _, userID := range restoredUserIDs {
+		currentReportsMutex.RLock()
+		report, ok := currentOngoingReports[userID]
+		currentReportsMutex.RUnlock()
+		if !ok {
+			continue
+		}
+
+		report.lock.Lock()
+		if report.isChoosingType {
+			sendReportTypePrompt(userID, false)
+			report.lock.Unlock()
+			continue
+		}
+
+		baseString := config.Messages.ReportRestored
+		baseString = strings.ReplaceAll(baseString, "{{QUESTION_NUMBER}}", strconv.Itoa(int(report.currentQuestionIndex)+1))
+		baseString = strings.ReplaceAll(baseString, "{{QUESTION_COUNT}}", strconv.Itoa(len(report.data)))
+		baseString = strings.ReplaceAll(baseString, "{{CANCEL_COMMAND}}", config.BotDMCommandPrefix+config.BotDMCommandCancel)
+		sendMessageToDM(baseString, userID)
+
+		if report.isInSubmitMenu {
+			handleSubmittingProcess(report, userID)
+		} else {
+			sendReportQuestion(report, userID, false)
+		}
+		report.lock.Unlock()
+	}
+}
+
+func logStorageError(action string, err error) {
+	log.Println("Unable to " + action + " in the storage: " + err.Error())
+}
+
+func newStoredReport(report *reportData) *storedReport {
+	answers := make([]string, len(report.data))
+	skipped := make([]bool, len(report.data))
+	questions := make([]string, len(report.data))
+	for index, value := range report.data {
+		answers[index] = value.answer
+		skipped[index] = value.skipped
+		questions[index] = value.question.Question
+	}
+
+	attachments := make([]string, len(report.attachments))
+	attachmentQuestions := make([]int, len(report.attachments))
+	attachmentSizes := make([]int, len(report.attachments))
+	attachmentNames := make([]string, len(report.attachments))
+	attachmentLogFindings := make([][]logFinding, len(report.attachments))
+	for index, value := range report.attachments {
+		attachments[index] = value.link
+		attachmentQuestions[index] = value.questionIndex
+		attachmentSizes[index] = value.size
+		attachmentNames[index] = value.fileName
+		attachmentLogFindings[index] = value.logFindings
+	}
+
+	reportTypeID := ""
+	if report.reportType != nil {
+		reportTypeID = report.reportType.ID
+	}
+
+	return &storedReport{
+		ReportType:            reportTypeID,
+		CurrentQuestionIndex:  report.currentQuestionIndex,
+		LastInteraction:       report.lastInteraction,
+		Questions:             questions,
+		Answers:               answers,
+		Skipped:               skipped,
+		Attachments:           attachments,
+		AttachmentQuestions:   attachmentQuestions,
+		AttachmentSizes:       attachmentSizes,
+		AttachmentNames:       attachmentNames,
+		AttachmentLogFindings: attachmentLogFindings,
+		IsInSubmitMenu:        report.isInSubmitMenu,
+		CanSubmit:             report.canSubmit,
+		CanEdit:               report.canEdit,
+		HasReachedEnd:         report.hasReachedEnd,
+		ShouldReadAnswer:      report.shouldReadAnswer,
+		IsChoosingType:        report.isChoosingType,
+	}
+}
+
+func (stored *storedReport) toReportData() *reportData {
+	report := &reportData{
+		attachments:          make([]reportAttachment, len(stored.Attachments)),
+		currentQuestionIndex: stored.CurrentQuestionIndex,
+		// The downtime of the bot shouldn't count towards the timeout of the user
+		lastInteraction:  time.Now(),
+		lock:             new(sync.Mutex),
+		canEdit:          stored.CanEdit,
+		canSubmit:        stored.CanSubmit,
+		hasReachedEnd:    stored.HasReachedEnd,
+		shouldReadAnswer: stored.ShouldReadAnswer,
+		isInSubmitMenu:   stored.IsInSubmitMenu,
+		isChoosingType:   stored.IsChoosingType,
+	}
+
+	// Reports stored by older versions don't know which question their attachments belong to
+	for index, attachmentLink := range stored.Attachments {
+		report.attachments[index] = reportAttachment{link: attachmentLink, questionIndex: -1}
+		if len(stored.AttachmentQuestions) == len(stored.Attachments) {
+			report.attachments[index].questionIndex = stored.AttachmentQuestions[index]
+		}
+		if len(stored.AttachmentSizes) == len(stored.Attachments) {
+			report.attachments[index].size = stored.AttachmentSizes[index]
+		}
+		if len(stored.AttachmentNames) == len(stored.Attachments) {
+			report.attachments[index].fileName = stored.AttachmentNames[index]
+		}
+		if len(stored.AttachmentLogFindings) == len(stored.Attachments) {
+			report.attachments[index].logFindings = stored.AttachmentLogFindings[index]
+		}
+	}
+
+	if stored.IsChoosingType {
+		return report
+	}
+
+	report.reportType = findReportType(stored.ReportType)
+	if report.reportType == nil {
+		return nil
+	}
+
+	questions := buildReportQuestions(report.reportType)
+	if len(questions) != len(stored.Answers) || len(questions) != len(stored.Questions) || int(stored.CurrentQuestionIndex) >= len(questions) {
+		return nil
+	}
+
+	for index := range questions {
+		if questions[index].question.Question != stored.Questions[index] {
+			return nil
+		}
+		questions[index].answer = stored.Answers[index]
+		questions[index].skipped = index < len(stored.Skipped) && stored.Skipped[index]
+	}
+
+	report.data = questions
+	return report
+}
+
+// fileReportStore keeps all ongoing reports in a single JSON file which is rewritten on every change.
+// Submitted reports are archived as one JSON file per report in the archive directory.
+type fileReportStore struct {
+	path             string
+	archiveDirectory string
+	reports          map[string]*storedReport
+	relays           map[string]*storedRelayConversation
+	// The threads and issues of every archived report, that way finding their report doesn't go through the whole archive
+	threadReportIDs map[string]uint64
+	issueReportIDs  map[string]uint64
+	lastReportID    uint64
+	lock            *sync.Mutex
+}
+
+func newFileReportStore(path, archiveDirectory string) (*fileReportStore, error) {
+	store := &fileReportStore{
+		path:             path,
+		archiveDirectory: archiveDirectory,
+		reports:          make(map[string]*storedReport),
+		relays:           make(map[string]*storedRelayConversation),
+		threadReportIDs:  make(map[string]uint64),
+		issueReportIDs:   make(map[string]uint64),
+		lock:             new(sync.Mutex),
+	}
+
+	if err := os.MkdirAll(archiveDirectory, 0755); err != nil {
+		return nil, err
+	}
+
+	// The saved counter is where we continue counting from, the archived reports are checked as well
+	// since older versions of the bot didn't save the counter
+	counterBytes, counterErr := ioutil.ReadFile(store.lastReportIDPath())
+	if counterErr != nil && !os.IsNotExist(counterErr) {
+		return nil, counterErr
+	}
+	if counterErr == nil {
+		lastReportID, parseErr := strconv.ParseUint(strings.TrimSpace(string(counterBytes)), 10, 64)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		store.lastReportID = lastReportID
+	}
+
+	archivedFiles, readErr := ioutil.ReadDir(archiveDirectory)
+	if readErr != nil {
+		return nil, readErr
+	}
+	for _, file := range archivedFiles {
+		reportID, parseErr := strconv.ParseUint(strings.TrimSuffix(file.Name(), ".json"), 10, 64)
+		if parseErr != nil {
+			continue
+		}
+
+		if reportID > store.lastReportID {
+			store.lastReportID = reportID
+		}
+
+		report, loadErr := store.loadArchivedReport(reportID)
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		store.indexArchivedReport(report)
+	}
+
+	if readErr := readJSONFile(path, &store.reports); readErr != nil {
+		return nil, readErr
+	}
+
+	if readErr := readJSONFile(store.relaysPath(), &store.relays); readErr != nil {
+		return nil, readErr
+	}
+
+	return store, nil
+}
+
+// A file that doesn't exist yet is the same as an empty one
+func readJSONFile(path string, value interface{}) error {
+	fileBytes, fileErr := ioutil.ReadFile(path)
+	if os.IsNotExist(fileErr) {
+		return nil
+	}
+	if fileErr != nil {
+		return fileErr
+	}
+
+	if len(fileBytes) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(fileBytes, value)
+}
+
+func (store *fileReportStore) saveOngoingReport(userID string, report *storedReport) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	store.reports[userID] = report
+	return store.flush()
+}
+
+func (store *fileReportStore) deleteOngoingReport(userID string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	if _, ok := store.reports[userID]; !ok {
+		return nil
+	}
+
+	delete(store.reports, userID)
+	return store.flush()
+}
+
+func (store *fileReportStore) loadOngoingReports() (map[string]*storedReport, error) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	reports := make(map[string]*storedReport, len(store.reports))
+	for userID, report := range store.reports {
+		reports[userID] = report
+	}
+
+	return reports, nil
+}
+
+func (store *fileReportStore) nextReportID() (uint64, error) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	// The counter is saved before the ID is handed out, that way an ID is never used twice
+	// even when saving the report itself fails later on
+	reportID := store.lastReportID + 1
+	if writeErr := writeFileAtomically(store.lastReportIDPath(), []byte(strconv.FormatUint(reportID, 10))); writeErr != nil {
+		return 0, writeErr
+	}
+
+	store.lastReportID = reportID
+	return reportID, nil
+}
+
+func (store *fileReportStore) saveArchivedReport(report *archivedReport) error {
+	reportBytes, jsonErr := json.MarshalIndent(report, "", "    ")
+	if jsonErr != nil {
+		return jsonErr
+	}
+
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	if writeErr := writeFileAtomically(store.archivedReportPath(report.ID), reportBytes); writeErr != nil {
+		return writeErr
+	}
+
+	store.indexArchivedReport(report)
+	return nil
+}
+
+// WARNING! This one does not lock the mutex needed to access the data!
+func (store *fileReportStore) indexArchivedReport(report *archivedReport) {
+	for _, threadID := range getArchivedReportThreads(report) {
+		store.threadReportIDs[threadID] = report.ID
+	}
+
+	if report.IssueURL != "" {
+		store.issueReportIDs[report.IssueURL] = report.ID
+	}
+}
+
+func (store *fileReportStore) loadArchivedReport(reportID uint64) (*archivedReport, error) {
+	fileBytes, fileErr := ioutil.ReadFile(store.archivedReportPath(reportID))
+	if os.IsNotExist(fileErr) {
+		return nil, errReportNotFound
+	}
+	if fileErr != nil {
+		return nil, fileErr
+	}
+
+	report := new(archivedReport)
+	if jsonErr := json.Unmarshal(fileBytes, report); jsonErr != nil {
+		return nil, jsonErr
+	}
+
+	return report, nil
+}
+
+func (store *fileReportStore) loadArchivedReportsByReporter(userID string) ([]*archivedReport, error) {
+	archivedFiles, readErr := ioutil.ReadDir(store.archiveDirectory)
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	reports := make([]*archivedReport, 0)
+	for _, file := range archivedFiles {
+		reportID, parseErr := strconv.ParseUint(strings.TrimSuffix(file.Name(), ".json"), 10, 64)
+		if parseErr != nil {
+			continue
+		}
+
+		report, loadErr := store.loadArchivedReport(reportID)
+		if loadErr != nil {
+			return nil, loadErr
+		}
+
+		if report.ReporterID == userID {
+			reports = append(reports, report)
+		}
+	}
+
+	// The directory is sorted by name, not by number
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].ID < reports[j].ID
+	})
+
+	return reports, nil
+}
+
+func (store *fileReportStore) loadArchivedReportByThread(threadID string) (*archivedReport, error) {
+	store.lock.Lock()
+	reportID, ok := store.threadReportIDs[threadID]
+	store.lock.Unlock()
+
+	if !ok {
+		return nil, errReportNotFound
+	}
+
+	return store.loadArchivedReport(reportID)
+}
+
+func (store *fileReportStore) loadArchivedReportByIssue(issueURL string) (*archivedReport, error) {
+	store.lock.Lock()
+	reportID, ok := store.issueReportIDs[issueURL]
+	store.lock.Unlock()
+
+	if !ok {
+		return nil, errReportNotFound
+	}
+
+	return store.loadArchivedReport(reportID)
+}
+
+func (store *fileReportStore) saveRelayConversation(userID string, conversation *storedRelayConversation) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	store.relays[userID] = conversation
+	return store.flushRelays()
+}
+
+func (store *fileReportStore) deleteRelayConversation(userID string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	if _, ok := store.relays[userID]; !ok {
+		return nil
+	}
+
+	delete(store.relays, userID)
+	return store.flushRelays()
+}
+
+func (store *fileReportStore) loadRelayConversations() (map[string]*storedRelayConversation, error) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	conversations := make(map[string]*storedRelayConversation, len(store.relays))
+	for userID, conversation := range store.relays {
+		conversations[userID] = conversation
+	}
+
+	return conversations, nil
+}
+
+func (store *fileReportStore) relaysPath() string {
+	return filepath.Join(filepath.Dir(store.path), relayConversationsFileName)
+}
+
+func (store *fileReportStore) lastReportIDPath() string {
+	return filepath.Join(store.archiveDirectory, lastReportIDFileName)
+}
+
+func (store *fileReportStore) archivedReportPath(reportID uint64) string {
+	return filepath.Join(store.archiveDirectory, strconv.FormatUint(reportID, 10)+".json")
+}
+
+func (store *fileReportStore) close() error {
+	return nil
+}
+
+// Writes the reports to a temporary file first so a crash halfway through never leaves a corrupt file behind.
+// WARNING! This one does not lock the mutex needed to access the data!
+func (store *fileReportStore) flush() error {
+	fileBytes, jsonErr := json.Marshal(store.reports)
+	if jsonErr != nil {
+		return jsonErr
+	}
+
+	return writeFileAtomically(store.path, fileBytes)
+}
+
+// WARNING! This one does not lock the mutex needed to access the data!
+func (store *fileReportStore) flushRelays() error {
+	fileBytes, jsonErr := json.Marshal(store.relays)
+	if jsonErr != nil {
+		return jsonErr
+	}
+
+	return writeFileAtomically(store.relaysPath(), fileBytes)
+}
+
+func writeFileAtomically(path string, data []byte) error {
+	tempPath := path + ".tmp"
+	if writeErr := ioutil.WriteFile(tempPath, data, 0644); writeErr != nil {
+		return writeErr
+	}
+
+	return os.Rename(tempPath, path)
+}
+
+// boltReportStore keeps all ongoing reports in an embedded bbolt database
+type boltReportStore struct {
+	database *bolt.DB
+}
+
+func newBoltReportStore(path string) (*boltReportStore, error) {
+	database, openErr := bolt.Open(path, 0644, &bolt.Options{Timeout: 5 * time.Second})
+	if openErr != nil {
+		return nil, openErr
+	}
+
+	updateErr := database.Update(func(tx *bolt.Tx) error {
+		// Databases of older versions don't have the indexes yet, those are built from the archive once
+		buildIndexes := tx.Bucket(reportThreadsBucket) == nil || tx.Bucket(reportIssuesBucket) == nil
+
+		for _, bucket := range [][]byte{ongoingReportsBucket, archivedReportsBucket, reportThreadsBucket, reportIssuesBucket, relaysBucket} {
+			if _, bucketErr := tx.CreateBucketIfNotExists(bucket); bucketErr != nil {
+				return bucketErr
+			}
+		}
+
+		if !buildIndexes {
+			return nil
+		}
+
+		return tx.Bucket(archivedReportsBucket).ForEach(func(key, value []byte) error {
+			report := new(archivedReport)
+			if jsonErr := json.Unmarshal(value, report); jsonErr != nil {
+				return jsonErr
+			}
+			return indexBoltArchivedReport(tx, report)
+		})
+	})
+	if updateErr != nil {
+		database.Close()
+		return nil, updateErr
+	}
+
+	return &boltReportStore{database: database}, nil
+}
+
+func (store *boltReportStore) saveOngoingReport(userID string, report *storedReport) error {
+	reportBytes, jsonErr := json.Marshal(report)
+	if jsonErr != nil {
+		return jsonErr
+	}
+
+	return store.database.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(ongoingReportsBucket).Put([]byte(userID), reportBytes)
+	})
+}
+
+func (store *boltReportStore) deleteOngoingReport(userID string) error {
+	return store.database.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(ongoingReportsBucket).Delete([]byte(userID))
+	})
+}
+
+func (store *boltReportStore) loadOngoingReports() (map[string]*storedReport, error) {
+	reports := make(map[string]*storedReport)
+
+	viewErr := store.database.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(ongoingReportsBucket).ForEach(func(key, value []byte) error {
+			report := new(storedReport)
+			if jsonErr := json.Unmarshal(value, report); jsonErr != nil {
+				return jsonErr
+			}
+
+			reports[string(key)] = report
+			return nil
+		})
+	})
+
+	return reports, viewErr
+}
+
+func (store *boltReportStore) nextReportID() (reportID uint64, err error) {
+	err = store.database.Update(func(tx *bolt.Tx) error {
+		var sequenceErr error
+		reportID, sequenceErr = tx.Bucket(archivedReportsBucket).NextSequence()
+		return sequenceErr
+	})
+	return reportID, err
+}
+
+func (store *boltReportStore) saveArchivedReport(report *archivedReport) error {
+	reportBytes, jsonErr := json.Marshal(report)
+	if jsonErr != nil {
+		return jsonErr
+	}
+
+	return store.database.Update(func(tx *bolt.Tx) error {
+		if putErr := tx.Bucket(archivedReportsBucket).Put(boltReportKey(report.ID), reportBytes); putErr != nil {
+			return putErr
+		}
+		return indexBoltArchivedReport(tx, report)
+	})
+}
+
+func indexBoltArchivedReport(tx *bolt.Tx, report *archivedReport) error {
+	for _, threadID := range getArchivedReportThreads(report) {
+		if putErr := tx.Bucket(reportThreadsBucket).Put([]byte(threadID), boltReportKey(report.ID)); putErr != nil {
+			return putErr
+		}
+	}
+
+	if report.IssueURL == "" {
+		return nil
+	}
+
+	return tx.Bucket(reportIssuesBucket).Put([]byte(report.IssueURL), boltReportKey(report.ID))
+}
+
+// Copies of a report have their own thread, those belong to the report just as much
+func getArchivedReportThreads(report *archivedReport) []string {
+	threadIDs := make([]string, 0, len(report.CopiedThreadIDs)+1)
+	if report.ThreadID != "" {
+		threadIDs = append(threadIDs, report.ThreadID)
+	}
+
+	return append(threadIDs, report.CopiedThreadIDs...)
+}
+
+func (store *boltReportStore) loadArchivedReport(reportID uint64) (*archivedReport, error) {
+	report := new(archivedReport)
+
+	viewErr := store.database.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(archivedReportsBucket).Get(boltReportKey(reportID))
+		if value == nil {
+			return errReportNotFound
+		}
+		return json.Unmarshal(value, report)
+	})
+	if viewErr != nil {
+		return nil, viewErr
+	}
+
+	return report, nil
+}
+
+func (store *boltReportStore) loadArchivedReportsByReporter(userID string) ([]*archivedReport, error) {
+	reports := make([]*archivedReport, 0)
+
+	viewErr := store.database.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(archivedReportsBucket).ForEach(func(key, value []byte) error {
+			report := new(archivedReport)
+			if jsonErr := json.Unmarshal(value, report); jsonErr != nil {
+				return jsonErr
+			}
+
+			if report.ReporterID == userID {
+				reports = append(reports, report)
+			}
+			return nil
+		})
+	})
+
+	return reports, viewErr
+}
+
+func (store *boltReportStore) loadArchivedReportByThread(threadID string) (*archivedReport, error) {
+	return store.loadIndexedArchivedReport(reportThreadsBucket, threadID)
+}
+
+func (store *boltReportStore) loadArchivedReportByIssue(issueURL string) (*archivedReport, error) {
+	return store.loadIndexedArchivedReport(reportIssuesBucket, issueURL)
+}
+
+func (store *boltReportStore) loadIndexedArchivedReport(indexBucket []byte, indexKey string) (*archivedReport, error) {
+	var reportID uint64
+
+	viewErr := store.database.View(func(tx *bolt.Tx) error {
+		key := tx.Bucket(indexBucket).Get([]byte(indexKey))
+		if key == nil {
+			return errReportNotFound
+		}
+		reportID = binary.BigEndian.Uint64(key)
+		return nil
+	})
+	if viewErr != nil {
+		return nil, viewErr
+	}
+
+	return store.loadArchivedReport(reportID)
+}
+
+func (store *boltReportStore) saveRelayConversation(userID string, conversation *storedRelayConversation) error {
+	conversationBytes, jsonErr := json.Marshal(conversation)
+	if jsonErr != nil {
+		return jsonErr
+	}
+
+	return store.database.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(relaysBucket).Put([]byte(userID), conversationBytes)
+	})
+}
+
+func (store *boltReportStore) deleteRelayConversation(userID string) error {
+	return store.database.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(relaysBucket).Delete([]byte(userID))
+	})
+}
+
+func (store *boltReportStore) loadRelayConversations() (map[string]*storedRelayConversation, error) {
+	conversations := make(map[string]*storedRelayConversation)
+
+	viewErr := store.database.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(relaysBucket).ForEach(func(key, value []byte) error {
+			conversation := new(storedRelayConversation)
+			if jsonErr := json.Unmarshal(value, conversation); jsonErr != nil {
+				return jsonErr
+			}
+
+			conversations[string(key)] = conversation
+			return nil
+		})
+	})
+
+	return conversations, viewErr
+}
+
+// Big endian keys keep the archived reports sorted by their ID
+func boltReportKey(reportID uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, reportID)
+	return key
+}
+
+func (store *boltReportStore) close() error {
+	return store.database.Close()
+}
+
+type storageConfig struct {
+	Type             string `json:"type"`
+	Path             string `json:"path"`
+	ArchiveDirectory string `json:"archive_directory"`
+}
+
+type storedReport struct {
+	ReportType            string         `json:"report_type"`
+	CurrentQuestionIndex  uint           `json:"current_question_index"`
+	LastInteraction       time.Time      `json:"last_interaction"`
+	Questions             []string       `json:"questions"`
+	Answers               []string       `json:"answers"`
+	Skipped               []bool         `json:"skipped,omitempty"`
+	Attachments           []string       `json:"attachments"`
+	AttachmentQuestions   []int          `json:"attachment_questions,omitempty"`
+	AttachmentSizes       []int          `json:"attachment_sizes,omitempty"`
+	AttachmentNames       []string       `json:"attachment_names,omitempty"`
+	AttachmentLogFindings [][]logFinding `json:"attachment_log_findings,omitempty"`
+
+	IsInSubmitMenu   bool `json:"is_in_submit_menu"`
+	CanSubmit        bool `json:"can_submit"`
+	CanEdit          bool `json:"can_edit"`
+	HasReachedEnd    bool `json:"has_reached_end"`
+	ShouldReadAnswer bool `json:"should_read_answer"`
+	IsChoosingType   bool `json:"is_choosing_type"`
+}
+
+type storedRelayConversation struct {
+	ReportID        uint64    `json:"report_id"`
+	ThreadID        string    `json:"thread_id"`
+	ChannelID       string    `json:"channel_id"`
+	MessageID       string    `json:"message_id"`
+	LastInteraction time.Time `json:"last_interaction"`
+}
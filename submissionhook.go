@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const defaultSubmissionHookTimeoutSeconds = 10
+
+// The report as the hook receives it on stdin
+type submissionHookInput struct {
+	ReporterID  string           `json:"reporter_id"`
+	ReportType  string           `json:"report_type"`
+	Answers     []archivedAnswer `json:"answers"`
+	Attachments []string         `json:"attachments"`
+	LogSummary  string           `json:"log_summary,omitempty"`
+}
+
+// The hook can print this to stdout, printing nothing at all is fine as well
+type submissionHookOutput struct {
+	Fields []reportField `json:"fields"`
+	Veto   string        `json:"veto"`
+}
+
+type reportField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Runs the configured command right before a report is posted. When the hook vetoes the report the reason is returned,
+// otherwise the fields it returned are added to the report. A broken hook never blocks a report, it only ends up in the log.
+func runSubmissionHook(report *reportData, userID string) (vetoReason string, vetoed bool) {
+	// Fields of an earlier run that got vetoed don't belong to this one
+	report.hookFields = nil
+
+	if len(config.SubmissionHook.Command) == 0 {
+		return "", false
+	}
+
+	input, jsonErr := json.Marshal(submissionHookInput{
+		ReporterID:  userID,
+		ReportType:  report.reportType.ID,
+		Answers:     generateArchivedAnswers(report),
+		Attachments: getAttachmentLinks(report),
+		LogSummary:  generateLogSummary(report),
+	})
+	if jsonErr != nil {
+		log.Println("Unable to generate the input of the submission hook: " + jsonErr.Error())
+		return "", false
+	}
+
+	timeoutSeconds := config.SubmissionHook.TimeoutSeconds
+	if timeoutSeconds == 0 {
+		timeoutSeconds = defaultSubmissionHookTimeoutSeconds
+	}
+
+	var stdout, stderr bytes.Buffer
+	command := exec.Command(config.SubmissionHook.Command[0], config.SubmissionHook.Command[1:]...)
+	command.Stdin = bytes.NewReader(input)
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+	prepareSubmissionHookProcess(command)
+
+	if runErr := runSubmissionHookCommand(command, time.Duration(timeoutSeconds)*time.Second); runErr != nil {
+		log.Println("The submission hook failed for the report of user " + userID + ": " + runErr.Error() + " " + strings.TrimSpace(stderr.String()))
+		return "", false
+	}
+
+	if len(bytes.TrimSpace(stdout.Bytes())) == 0 {
+		return "", false
+	}
+
+	var output submissionHookOutput
+	if jsonErr := json.Unmarshal(stdout.Bytes(), &output); jsonErr != nil {
+		log.Println("The submission hook returned invalid JSON for the report of user " + userID + ": " + jsonErr.Error())
+		return "", false
+	}
+
+	if veto := strings.TrimSpace(output.Veto); veto != "" {
+		return veto, true
+	}
+
+	report.hookFields = make([]reportField, 0, len(output.Fields))
+	for _, field := range output.Fields {
+		if strings.TrimSpace(field.Name) == "" || strings.TrimSpace(field.Value) == "" {
+			continue
+		}
+
+		// Embed field names can't be any longer, the same name is used everywhere else so it's cut here
+		field.Name = truncateText(field.Name, maxEmbedFieldNameLength)
+		report.hookFields = append(report.hookFields, field)
+	}
+
+	return "", false
+}
+
+// Waits for the hook to finish. When it takes too long the hook is killed together with the processes it started,
+// otherwise those would keep the output open and the report would wait for them anyway.
+func runSubmissionHookCommand(command *exec.Cmd, timeout time.Duration) error {
+	if startErr := command.Start(); startErr != nil {
+		return startErr
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- command.Wait()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case waitErr := <-done:
+		return waitErr
+	case <-timer.C:
+		if killErr := killSubmissionHookProcess(command); killErr != nil {
+			log.Println("Unable to kill the submission hook: " + killErr.Error())
+		}
+		<-done
+		return errors.New("the submission hook took longer than " + timeout.String())
+	}
+}
+
+func formatReportField(field reportField) string {
+	baseString := strings.ReplaceAll(config.Messages.ReportField, "{{FIELD_NAME}}", field.Name)
+	return strings.ReplaceAll(baseString, "{{FIELD_VALUE}}", field.Value)
+}
+
+type submissionHookConfig struct {
+	Command        []string `json:"command"`
+	TimeoutSeconds uint     `json:"timeout_seconds"`
+}
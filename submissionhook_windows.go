@@ -0,0 +1,15 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"os/exec"
+)
+
+// Windows doesn't have process groups that can be killed at once, only the hook itself is killed there
+func prepareSubmissionHookProcess(command *exec.Cmd) {}
+
+func killSubmissionHookProcess(command *exec.Cmd) error {
+	return command.Process.Kill()
+}
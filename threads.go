@@ -0,0 +1,123 @@
+package main
+
+import (
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	maxThreadNameLength        = 100
+	maxAppliedForumTags        = 5
+	reportThreadArchiveMinutes = 10080
+)
+
+// Posts the report in its channel. Forum channels only accept posts, so there the report becomes the starting
+// message of a new post. In regular channels a thread is opened on the report when that's enabled in the config.
+func postReport(report *reportData, archived *archivedReport, channelID string, messages []*discordgo.MessageSend) error {
+	channel := getChannel(channelID)
+
+	if channel != nil && channel.Type == discordgo.ChannelTypeGuildForum {
+		thread, threadErr := botSession.ForumThreadStartComplex(channelID, &discordgo.ThreadStart{
+			Name:        generateThreadTitle(report, archived.ID),
+			AppliedTags: findForumTags(report, channel),
+		}, messages[0])
+		if threadErr != nil {
+			return threadErr
+		}
+
+		// The starting message of a forum post has the same ID as the post itself
+		archived.ChannelID = thread.ID
+		archived.MessageID = thread.ID
+		archived.ThreadID = thread.ID
+	} else {
+		message, messageErr := botSession.ChannelMessageSendComplex(channelID, messages[0])
+		if messageErr != nil {
+			return messageErr
+		}
+
+		archived.ChannelID = message.ChannelID
+		archived.MessageID = message.ID
+
+		if config.CreateReportThreads {
+			thread, threadErr := botSession.MessageThreadStartComplex(message.ChannelID, message.ID, &discordgo.ThreadStart{
+				Name:                generateThreadTitle(report, archived.ID),
+				AutoArchiveDuration: reportThreadArchiveMinutes,
+			})
+			if threadErr != nil {
+				log.Println("Unable to create a thread for a report: " + threadErr.Error())
+			} else {
+				archived.ThreadID = thread.ID
+			}
+		}
+	}
+
+	// The rest of a split report goes in the thread when there is one, otherwise right below the first message
+	followUpChannelID := archived.ChannelID
+	if archived.ThreadID != "" {
+		followUpChannelID = archived.ThreadID
+	}
+
+	for _, followUp := range messages[1:] {
+		botSession.ChannelMessageSendComplex(followUpChannelID, followUp)
+	}
+
+	return nil
+}
+
+// The title is the answer to the title question of the report type, the report type name is used when there isn't one
+func generateThreadTitle(report *reportData, reportID uint64) string {
+	title := report.reportType.Name
+	if report.reportType.TitleQuestionID != "" {
+		index := findQuestionIndexByID(report, report.reportType.TitleQuestionID, len(report.data))
+		if index != -1 && isQuestionActive(report, index) && report.data[index].answer != "" {
+			title = report.data[index].answer
+		}
+	}
+
+	if reportID != 0 {
+		title = strings.ReplaceAll(config.Messages.ReportThreadTitle, "{{TITLE}}", title)
+		title = replaceReportIDPlaceholder(title, reportID)
+	}
+
+	// Thread names are a single line
+	return truncateText(strings.Join(strings.Fields(title), " "), maxThreadNameLength)
+}
+
+// Fixed answers can be mapped to the name of a forum tag, the tags that don't exist in the forum are ignored
+func findForumTags(report *reportData, forum *discordgo.Channel) []string {
+	tagIDs := make([]string, 0)
+	for index, value := range report.data {
+		if len(value.question.ForumTags) == 0 || !isQuestionActive(report, index) {
+			continue
+		}
+
+		for answer, tagName := range value.question.ForumTags {
+			if !strings.EqualFold(answer, value.answer) {
+				continue
+			}
+
+			for _, tag := range forum.AvailableTags {
+				if (strings.EqualFold(tag.Name, tagName) || tag.ID == tagName) && len(tagIDs) < maxAppliedForumTags {
+					tagIDs = append(tagIDs, tag.ID)
+				}
+			}
+		}
+	}
+
+	return tagIDs
+}
+
+func getChannel(channelID string) *discordgo.Channel {
+	if channel, stateErr := botSession.State.Channel(channelID); stateErr == nil {
+		return channel
+	}
+
+	channel, channelErr := botSession.Channel(channelID)
+	if channelErr != nil {
+		return nil
+	}
+
+	return channel
+}
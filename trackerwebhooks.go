@@ -0,0 +1,333 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	gitHubWebhookPath = "/webhooks/github"
+	gitLabWebhookPath = "/webhooks/gitlab"
+	jiraWebhookPath   = "/webhooks/jira"
+
+	maxWebhookPayloadSize = 5 * 1024 * 1024
+	webhookServerTimeout  = 30 * time.Second
+
+	// The reasons GitHub gives for closing an issue
+	issueCloseReasonCompleted  = "completed"
+	issueCloseReasonNotPlanned = "not_planned"
+	issueCloseReasonDuplicate  = "duplicate"
+)
+
+// Listens for the webhooks of the issue tracker, that way closing or relabelling an issue also updates the report on Discord.
+// Every tracker has its own secret, the endpoint of a tracker without a secret is disabled.
+func startTrackerWebhookServer() {
+	mux := http.NewServeMux()
+	mux.HandleFunc(gitHubWebhookPath, handleGitHubWebhook)
+	mux.HandleFunc(gitLabWebhookPath, handleGitLabWebhook)
+	mux.HandleFunc(jiraWebhookPath, handleJiraWebhook)
+
+	server := &http.Server{
+		Addr:         config.TrackerWebhooks.ListenAddress,
+		Handler:      mux,
+		ReadTimeout:  webhookServerTimeout,
+		WriteTimeout: webhookServerTimeout,
+	}
+
+	log.Println("Listening for issue tracker webhooks on " + config.TrackerWebhooks.ListenAddress)
+	if serveErr := server.ListenAndServe(); serveErr != nil {
+		log.Println("The webhook server stopped: " + serveErr.Error())
+	}
+}
+
+func handleGitHubWebhook(writer http.ResponseWriter, request *http.Request) {
+	payload, ok := readWebhookPayload(writer, request, config.TrackerWebhooks.GitHubSecret)
+	if !ok {
+		return
+	}
+
+	// GitHub signs the payload with the secret, the signature is sent as "sha256=<hex>"
+	if !isValidWebhookSignature(payload, request.Header.Get("X-Hub-Signature-256"), config.TrackerWebhooks.GitHubSecret) {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	// Only issue events matter, the ping GitHub sends after creating the webhook ends up here as well
+	if request.Header.Get("X-GitHub-Event") != "issues" {
+		writer.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var event struct {
+		Action string `json:"action"`
+		Issue  struct {
+			HTMLURL     string `json:"html_url"`
+			StateReason string `json:"state_reason"`
+		} `json:"issue"`
+		Label struct {
+			Name string `json:"name"`
+		} `json:"label"`
+	}
+
+	if jsonErr := json.Unmarshal(payload, &event); jsonErr != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	switch event.Action {
+	case "closed":
+		syncTrackerStatus(event.Issue.HTMLURL, findClosedStatus(event.Issue.StateReason))
+	case "reopened":
+		syncTrackerStatus(event.Issue.HTMLURL, config.TrackerWebhooks.ReopenedStatus)
+	case "labeled":
+		syncTrackerStatus(event.Issue.HTMLURL, findLabelStatus([]string{event.Label.Name}))
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func handleGitLabWebhook(writer http.ResponseWriter, request *http.Request) {
+	payload, ok := readWebhookPayload(writer, request, config.TrackerWebhooks.GitLabSecret)
+	if !ok {
+		return
+	}
+
+	// GitLab doesn't sign its webhooks, it sends the secret token as it is instead
+	token := request.Header.Get("X-Gitlab-Token")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(config.TrackerWebhooks.GitLabSecret)) != 1 {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	type gitLabLabel struct {
+		Title string `json:"title"`
+	}
+
+	var event struct {
+		ObjectKind       string `json:"object_kind"`
+		ObjectAttributes struct {
+			URL            string  `json:"url"`
+			Action         string  `json:"action"`
+			DuplicatedToID *uint64 `json:"duplicated_to_id"`
+		} `json:"object_attributes"`
+		Changes struct {
+			Labels struct {
+				Previous []gitLabLabel `json:"previous"`
+				Current  []gitLabLabel `json:"current"`
+			} `json:"labels"`
+		} `json:"changes"`
+	}
+
+	if jsonErr := json.Unmarshal(payload, &event); jsonErr != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if event.ObjectKind != "issue" {
+		writer.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	switch event.ObjectAttributes.Action {
+	case "close":
+		// GitLab doesn't have a reason for closing an issue, only issues closed as a duplicate point to the original one
+		reason := issueCloseReasonCompleted
+		if event.ObjectAttributes.DuplicatedToID != nil {
+			reason = issueCloseReasonDuplicate
+		}
+		syncTrackerStatus(event.ObjectAttributes.URL, findClosedStatus(reason))
+	case "reopen":
+		syncTrackerStatus(event.ObjectAttributes.URL, config.TrackerWebhooks.ReopenedStatus)
+	case "update":
+		previous := make([]string, len(event.Changes.Labels.Previous))
+		for index, label := range event.Changes.Labels.Previous {
+			previous[index] = label.Title
+		}
+
+		current := make([]string, len(event.Changes.Labels.Current))
+		for index, label := range event.Changes.Labels.Current {
+			current[index] = label.Title
+		}
+
+		syncTrackerStatus(event.ObjectAttributes.URL, findLabelStatus(findAddedLabels(previous, current)))
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func handleJiraWebhook(writer http.ResponseWriter, request *http.Request) {
+	payload, ok := readWebhookPayload(writer, request, config.TrackerWebhooks.JiraSecret)
+	if !ok {
+		return
+	}
+
+	// Jira Cloud signs the payload the same way GitHub does, only the header is named differently
+	if !isValidWebhookSignature(payload, request.Header.Get("X-Hub-Signature"), config.TrackerWebhooks.JiraSecret) {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var event struct {
+		WebhookEvent string `json:"webhookEvent"`
+		Issue        struct {
+			Key string `json:"key"`
+		} `json:"issue"`
+		Changelog struct {
+			Items []struct {
+				Field      string `json:"field"`
+				FromString string `json:"fromString"`
+				ToString   string `json:"toString"`
+			} `json:"items"`
+		} `json:"changelog"`
+	}
+
+	if jsonErr := json.Unmarshal(payload, &event); jsonErr != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if event.WebhookEvent != "jira:issue_updated" || event.Issue.Key == "" {
+		writer.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	issueURL := getJiraIssueURL(config.IssueTracker.APIBaseURL, event.Issue.Key)
+	for _, item := range event.Changelog.Items {
+		switch item.Field {
+		case "status":
+			syncTrackerStatus(issueURL, findMappedStatus(config.TrackerWebhooks.JiraStatuses, []string{item.ToString}))
+		case "labels":
+			// Jira puts all labels in a single string separated by spaces
+			syncTrackerStatus(issueURL, findLabelStatus(findAddedLabels(strings.Fields(item.FromString), strings.Fields(item.ToString))))
+		}
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// Reads the body of a webhook request, when this returns false the response has already been written
+func readWebhookPayload(writer http.ResponseWriter, request *http.Request, secret string) (payload []byte, ok bool) {
+	if secret == "" {
+		writer.WriteHeader(http.StatusNotFound)
+		return nil, false
+	}
+
+	if request.Method != http.MethodPost {
+		writer.WriteHeader(http.StatusMethodNotAllowed)
+		return nil, false
+	}
+
+	payload, readErr := ioutil.ReadAll(io.LimitReader(request.Body, maxWebhookPayloadSize))
+	if readErr != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		return nil, false
+	}
+
+	return payload, true
+}
+
+func isValidWebhookSignature(payload []byte, signature, secret string) bool {
+	expectedSignature, decodeErr := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if decodeErr != nil || !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hmac.Equal(mac.Sum(nil), expectedSignature)
+}
+
+func findAddedLabels(previous, current []string) []string {
+	added := make([]string, 0)
+	for _, label := range current {
+		if !containsString(previous, label) {
+			added = append(added, label)
+		}
+	}
+
+	return added
+}
+
+// Issues that were closed without fixing anything shouldn't tell the reporter their bug has been fixed.
+// Older configs don't have a status for those reasons, they get the status that matches the reason.
+func findClosedStatus(reason string) reportStatus {
+	switch reason {
+	case issueCloseReasonNotPlanned:
+		if config.TrackerWebhooks.NotPlannedStatus != "" {
+			return config.TrackerWebhooks.NotPlannedStatus
+		}
+		return reportStatusWontFix
+	case issueCloseReasonDuplicate:
+		if config.TrackerWebhooks.DuplicateStatus != "" {
+			return config.TrackerWebhooks.DuplicateStatus
+		}
+		return reportStatusDuplicate
+	}
+
+	return config.TrackerWebhooks.ClosedStatus
+}
+
+func findLabelStatus(labels []string) reportStatus {
+	return findMappedStatus(config.TrackerWebhooks.LabelStatuses, labels)
+}
+
+// Labels and statuses in trackers are case insensitive, when multiple values are mapped the last one wins
+func findMappedStatus(mapping map[string]reportStatus, values []string) reportStatus {
+	var found reportStatus
+	for _, value := range values {
+		for name, status := range mapping {
+			if strings.EqualFold(name, value) {
+				found = status
+			}
+		}
+	}
+
+	return found
+}
+
+// Updates the status of the report that belongs to the issue, the reporter is notified just like when the staff updates it
+func syncTrackerStatus(issueURL string, status reportStatus) {
+	if _, ok := reportStatusNames[status]; !ok || issueURL == "" {
+		return
+	}
+
+	archived, loadErr := reportStorage.loadArchivedReportByIssue(issueURL)
+	if loadErr != nil {
+		if loadErr != errReportNotFound {
+			logStorageError("load the archived report of issue "+issueURL, loadErr)
+		}
+		return
+	}
+
+	// There's no staff member behind the change, so the status line mentions the tracker instead.
+	// Trackers send an event for every change, there's no need to bother the reporter twice with the same status.
+	archived, found, changed := syncReportStatus(archived.ID, status)
+	if !found || !changed {
+		return
+	}
+
+	editPostedReport(archived)
+	notifyReporterOfStatusChange(archived)
+}
+
+type trackerWebhooksConfig struct {
+	ListenAddress    string                  `json:"listen_address"`
+	GitHubSecret     string                  `json:"github_secret"`
+	GitLabSecret     string                  `json:"gitlab_secret"`
+	JiraSecret       string                  `json:"jira_secret"`
+	ClosedStatus     reportStatus            `json:"closed_status"`
+	NotPlannedStatus reportStatus            `json:"not_planned_status"`
+	DuplicateStatus  reportStatus            `json:"duplicate_status"`
+	ReopenedStatus   reportStatus            `json:"reopened_status"`
+	LabelStatuses    map[string]reportStatus `json:"label_statuses"`
+	JiraStatuses     map[string]reportStatus `json:"jira_statuses"`
+}
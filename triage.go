@@ -0,0 +1,286 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	reportStatusButtonPrefix = "report_status:"
+)
+
+type reportStatus string
+
+const (
+	reportStatusOpen      reportStatus = "open"
+	reportStatusConfirmed reportStatus = "confirmed"
+	reportStatusDuplicate reportStatus = "duplicate"
+	reportStatusWontFix   reportStatus = "wont_fix"
+	reportStatusFixed     reportStatus = "fixed"
+	reportStatusNeedInfo  reportStatus = "need_info"
+)
+
+// The order of this slice is also the order of the buttons underneath a report
+var triageStatuses = []struct {
+	status reportStatus
+	label  string
+	style  discordgo.ButtonStyle
+}{
+	{reportStatusConfirmed, "Confirm", discordgo.SuccessButton},
+	{reportStatusDuplicate, "Duplicate", discordgo.SecondaryButton},
+	{reportStatusWontFix, "Won't Fix", discordgo.DangerButton},
+	{reportStatusFixed, "Fixed", discordgo.PrimaryButton},
+	{reportStatusNeedInfo, "Need Info", discordgo.SecondaryButton},
+}
+
+var reportStatusNames = map[reportStatus]string{
+	reportStatusOpen:      "Open",
+	reportStatusConfirmed: "Confirmed",
+	reportStatusDuplicate: "Duplicate",
+	reportStatusWontFix:   "Won't Fix",
+	reportStatusFixed:     "Fixed",
+	reportStatusNeedInfo:  "Need Info",
+}
+
+// Makes sure two staff members can't update the same archived report at the same time
+var archivedReportsMutex = new(sync.Mutex)
+
+func generateTriageComponents(reportID uint64) []discordgo.MessageComponent {
+	buttons := make([]discordgo.MessageComponent, len(triageStatuses))
+	for index, value := range triageStatuses {
+		buttons[index] = discordgo.Button{
+			CustomID: reportStatusButtonPrefix + string(value.status) + ":" + strconv.FormatUint(reportID, 10),
+			Label:    value.label,
+			Style:    value.style,
+		}
+	}
+
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: buttons,
+		},
+		generateAskReporterComponent(reportID),
+	}
+}
+
+func handleTriageButton(session *discordgo.Session, interaction *discordgo.InteractionCreate, customID string) {
+	if interaction.Member == nil || !isStaffMember(interaction.Member) {
+		respondEphemeral(session, interaction, config.Messages.InteractionNotAllowed)
+		return
+	}
+
+	split := strings.Split(strings.TrimPrefix(customID, reportStatusButtonPrefix), ":")
+	if len(split) != 2 {
+		return
+	}
+
+	status := reportStatus(split[0])
+	reportID, parseErr := strconv.ParseUint(split[1], 10, 64)
+	if _, ok := reportStatusNames[status]; !ok || parseErr != nil {
+		return
+	}
+
+	archived, found, changed := updateReportStatus(reportID, status, interaction.Member.User.ID, "")
+	if !found {
+		respondEphemeral(session, interaction, replaceReportIDPlaceholder(config.Messages.ReportNotFound, reportID))
+		return
+	}
+
+	session.InteractionRespond(interaction.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    generatePostedReportContent(archived),
+			Embeds:     getPostedReportEmbeds(archived),
+			Components: generateTriageComponents(archived.ID),
+		},
+	})
+
+	// Pressing the button of the current status again shouldn't bother the reporter a second time
+	if !changed {
+		return
+	}
+
+	notifyReporterOfStatusChange(archived)
+	handleIssueTrackerStatusChange(archived)
+}
+
+// Handles the status command staff can type in a guild channel, this is the only way to add a note for the reporter.
+// The format is: <prefix><status command> <report id> <status> [note]
+func handleStaffStatusCommand(message *discordgo.MessageCreate) {
+	if message.Member == nil || !strings.HasPrefix(strings.ToLower(message.Content), config.BotDMCommandPrefix+config.BotStaffCommandStatus+" ") {
+		return
+	}
+
+	// Only the member roles are sent with guild messages, the user is part of the message itself
+	if !isStaffMember(message.Member) {
+		return
+	}
+
+	split := strings.SplitN(message.Content, " ", 4)
+	if len(split) < 3 {
+		botSession.ChannelMessageSendReply(message.ChannelID, config.Messages.InvalidStatusCommand, message.Reference())
+		return
+	}
+
+	reportID, parseErr := strconv.ParseUint(strings.TrimPrefix(split[1], "#"), 10, 64)
+	status := reportStatus(strings.ToLower(split[2]))
+	if _, ok := reportStatusNames[status]; !ok || parseErr != nil {
+		botSession.ChannelMessageSendReply(message.ChannelID, config.Messages.InvalidStatusCommand, message.Reference())
+		return
+	}
+
+	note := ""
+	if len(split) == 4 {
+		note = strings.ReplaceAll(split[3], "@", "at")
+	}
+
+	archived, found, changed := updateReportStatus(reportID, status, message.Author.ID, note)
+	if !found {
+		botSession.ChannelMessageSendReply(message.ChannelID, replaceReportIDPlaceholder(config.Messages.ReportNotFound, reportID), message.Reference())
+		return
+	}
+
+	botSession.MessageReactionAdd(message.ChannelID, message.ID, "✅")
+	if !changed {
+		return
+	}
+
+	editPostedReport(archived)
+	notifyReporterOfStatusChange(archived)
+	handleIssueTrackerStatusChange(archived)
+}
+
+func editPostedReport(archived *archivedReport) {
+	if archived.ChannelID == "" || archived.MessageID == "" {
+		return
+	}
+
+	messageEdit := discordgo.NewMessageEdit(archived.ChannelID, archived.MessageID).SetContent(generatePostedReportContent(archived))
+	components := generateTriageComponents(archived.ID)
+	messageEdit.Components = &components
+	botSession.ChannelMessageEditComplex(messageEdit)
+}
+
+// Only the statuses that have a template configured will be sent to the reporter
+func notifyReporterOfStatusChange(archived *archivedReport) {
+	template, ok := config.Messages.StatusChangedNotifications[archived.Status]
+	if !ok || template == "" {
+		return
+	}
+
+	staffNote := ""
+	if archived.StaffNote != "" {
+		staffNote = strings.ReplaceAll(config.Messages.StaffNote, "{{STAFF_NOTE}}", archived.StaffNote)
+	}
+
+	baseString := replaceReportIDPlaceholder(template, archived.ID)
+	baseString = strings.ReplaceAll(baseString, "{{STATUS}}", reportStatusNames[archived.Status])
+	baseString = strings.ReplaceAll(baseString, "{{STAFF_NOTE}}", staffNote)
+	sendMessageToDM(baseString, archived.ReporterID)
+}
+
+// Nothing is saved when the report already has the status and note, in that case changed is false
+func updateReportStatus(reportID uint64, status reportStatus, staffID, note string) (archived *archivedReport, found, changed bool) {
+	return setReportStatus(reportID, status, staffID, &note)
+}
+
+// Trackers only know about the status, the note of the staff is kept as it is
+func syncReportStatus(reportID uint64, status reportStatus) (archived *archivedReport, found, changed bool) {
+	return setReportStatus(reportID, status, "", nil)
+}
+
+// When the note is nil the current note is kept
+func setReportStatus(reportID uint64, status reportStatus, staffID string, note *string) (archived *archivedReport, found, changed bool) {
+	archivedReportsMutex.Lock()
+	defer archivedReportsMutex.Unlock()
+
+	archived, loadErr := reportStorage.loadArchivedReport(reportID)
+	if loadErr != nil {
+		if loadErr != errReportNotFound {
+			logStorageError("load archived report #"+strconv.FormatUint(reportID, 10), loadErr)
+		}
+		return nil, false, false
+	}
+
+	if note == nil {
+		note = &archived.StaffNote
+	}
+
+	if archived.Status == status && archived.StaffNote == *note {
+		return archived, true, false
+	}
+
+	archived.Status = status
+	archived.StatusUpdatedBy = staffID
+	archived.StatusUpdatedAt = time.Now()
+	archived.StaffNote = *note
+	saveArchivedReportToStorage(archived)
+	fireArchivedReportEvent(reportEventStatusChanged, archived)
+
+	return archived, true, true
+}
+
+// The content of a posted report is the original report with the current status and issue underneath it
+func generatePostedReportContent(archived *archivedReport) string {
+	lines := ""
+	if archived.Status != "" && archived.Status != reportStatusOpen {
+		// Statuses without a staff member are synced from the issue tracker
+		statusLine := config.Messages.ReportStatusLine
+		if archived.StatusUpdatedBy == "" {
+			statusLine = config.Messages.ReportStatusLineTracker
+		}
+
+		statusLine = strings.ReplaceAll(statusLine, "{{STATUS}}", reportStatusNames[archived.Status])
+		lines += strings.ReplaceAll(statusLine, "{{STAFF_TAG}}", "<@"+archived.StatusUpdatedBy+">")
+	}
+
+	if archived.IssueURL != "" {
+		issueLine := strings.ReplaceAll(config.Messages.IssueLinkLine, "{{ISSUE_URL}}", archived.IssueURL)
+		lines += strings.ReplaceAll(issueLine, "{{ISSUE_ID}}", archived.IssueID)
+	}
+
+	// Reports are posted with room for these lines, reports from before that could still be too long
+	content := archived.Content
+	if utf8.RuneCountInString(content+lines) > maxMessageLength {
+		content = truncateText(content, maxMessageLength-utf8.RuneCountInString(lines))
+	}
+	content += lines
+
+	// Embed reports don't have any content, so the status is all there is
+	return strings.TrimSpace(content)
+}
+
+func getPostedReportEmbeds(archived *archivedReport) []*discordgo.MessageEmbed {
+	if archived.Embed == nil {
+		return nil
+	}
+
+	return []*discordgo.MessageEmbed{archived.Embed}
+}
+
+func isStaffMember(member *discordgo.Member) bool {
+	for _, roleID := range member.Roles {
+		for _, staffRoleID := range config.StaffRoleIDs {
+			if roleID == staffRoleID {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func respondEphemeral(session *discordgo.Session, interaction *discordgo.InteractionCreate, content string) {
+	session.InteractionRespond(interaction.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
@@ -1,11 +1,33 @@
 package main
 
 import (
+	"errors"
+	"math"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 )
 
+const (
+	validatorLength  = "length"
+	validatorRegex   = "regex"
+	validatorInteger = "integer"
+	validatorFloat   = "float"
+	validatorURL     = "url"
+	validatorSemver  = "semver"
+	validatorDate    = "date"
+	validatorEmail   = "email"
+
+	defaultDateFormat = "2006-01-02"
+)
+
+var semverPattern = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+
 var (
 	currentUsersOnReportCooldown = make(map[string]time.Time)
 	currentUsersOnReportMutex    = new(sync.RWMutex)
@@ -14,18 +36,19 @@ var (
 	userCooldownsMessagesMutex = new(sync.RWMutex)
 )
 
-func setReportCooldownForUser(userID string) {
+// Every report type has its own cooldown, so submitting a bug report doesn't block someone from giving feedback
+func setReportCooldownForUser(userID string, chosenType *reportType) {
 	currentUsersOnReportMutex.Lock()
 	defer currentUsersOnReportMutex.Unlock()
 
-	currentUsersOnReportCooldown[userID] = time.Now().Add(time.Duration(config.ReportCooldownMinutes) * time.Minute)
+	currentUsersOnReportCooldown[userID+":"+chosenType.ID] = time.Now().Add(time.Duration(*chosenType.CooldownMinutes) * time.Minute)
 }
 
-func isUserOnReportCooldown(userID string) bool {
+func isUserOnReportCooldown(userID, reportTypeID string) bool {
 	currentUsersOnReportMutex.RLock()
 	defer currentUsersOnReportMutex.RUnlock()
 
-	if cooldown, ok := currentUsersOnReportCooldown[userID]; ok {
+	if cooldown, ok := currentUsersOnReportCooldown[userID+":"+reportTypeID]; ok {
 		if time.Now().Before(cooldown) {
 			return true
 		}
@@ -89,3 +112,102 @@ func isValidFixedQuestionAnswer(report *reportData, content string) bool {
 
 	return true
 }
+
+// Runs all the validators of the current question, the first validator that fails decides the error message
+func validateAnswer(report *reportData, content string) (errorMessage string, valid bool) {
+	for _, validator := range report.data[report.currentQuestionIndex].question.Validators {
+		if !validator.isValid(strings.TrimSpace(content)) {
+			errorMessage = strings.ReplaceAll(validator.ErrorMessage, "{{MIN}}", formatValidatorLimit(validator.Min))
+			errorMessage = strings.ReplaceAll(errorMessage, "{{MAX}}", formatValidatorLimit(validator.Max))
+			return errorMessage, false
+		}
+	}
+
+	return "", true
+}
+
+func (validator *answerValidator) isValid(content string) bool {
+	switch validator.Type {
+	case validatorLength:
+		return isInRange(float64(utf8.RuneCountInString(content)), validator.Min, validator.Max)
+	case validatorRegex:
+		return validator.compiledPattern.MatchString(content)
+	case validatorInteger:
+		value, parseErr := strconv.ParseInt(content, 10, 64)
+		return parseErr == nil && isInRange(float64(value), validator.Min, validator.Max)
+	case validatorFloat:
+		value, parseErr := strconv.ParseFloat(strings.ReplaceAll(content, ",", "."), 64)
+		return parseErr == nil && !math.IsNaN(value) && isInRange(value, validator.Min, validator.Max)
+	case validatorURL:
+		parsedURL, parseErr := url.ParseRequestURI(content)
+		return parseErr == nil && (parsedURL.Scheme == "http" || parsedURL.Scheme == "https") && parsedURL.Host != ""
+	case validatorSemver:
+		return semverPattern.MatchString(content)
+	case validatorDate:
+		_, parseErr := time.Parse(validator.dateFormat(), content)
+		return parseErr == nil
+	case validatorEmail:
+		address, parseErr := mail.ParseAddress(content)
+		return parseErr == nil && address.Address == content
+	}
+
+	return true
+}
+
+// Makes sure the validators from the config can actually be used, this also compiles the regex patterns once
+func prepareAnswerValidators(questions []reportQuestion) error {
+	for questionIndex := range questions {
+		for validatorIndex := range questions[questionIndex].Validators {
+			validator := &questions[questionIndex].Validators[validatorIndex]
+
+			switch validator.Type {
+			case validatorRegex:
+				compiledPattern, compileErr := regexp.Compile(validator.Pattern)
+				if compileErr != nil {
+					return compileErr
+				}
+				validator.compiledPattern = compiledPattern
+			case validatorLength, validatorInteger, validatorFloat, validatorURL, validatorSemver, validatorDate, validatorEmail:
+			default:
+				return errors.New("unknown validator type \"" + validator.Type + "\" for question \"" + questions[questionIndex].Question + "\"")
+			}
+		}
+	}
+
+	return nil
+}
+
+func (validator *answerValidator) dateFormat() string {
+	if validator.Format == "" {
+		return defaultDateFormat
+	}
+
+	return validator.Format
+}
+
+func isInRange(value float64, min, max *float64) bool {
+	if min != nil && value < *min {
+		return false
+	}
+
+	return max == nil || value <= *max
+}
+
+func formatValidatorLimit(limit *float64) string {
+	if limit == nil {
+		return ""
+	}
+
+	return strconv.FormatFloat(*limit, 'f', -1, 64)
+}
+
+type answerValidator struct {
+	Type         string   `json:"type"`
+	Min          *float64 `json:"min,omitempty"`
+	Max          *float64 `json:"max,omitempty"`
+	Pattern      string   `json:"pattern,omitempty"`
+	Format       string   `json:"format,omitempty"`
+	ErrorMessage string   `json:"error_message"`
+
+	compiledPattern *regexp.Regexp
+}